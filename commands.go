@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/manifoldco/kubernetes-credentials/client/clientset/versioned"
+	"github.com/manifoldco/kubernetes-credentials/controller"
+	"github.com/manifoldco/kubernetes-credentials/logging"
+	"github.com/manifoldco/kubernetes-credentials/manifest"
+)
+
+// commands are the subcommands of the binary. Without a subcommand, the
+// controller is run.
+var commands = map[string]func(args []string) int{
+	"diff":     runDiff,
+	"export":   runExport,
+	"render":   runRender,
+	"validate": runValidate,
+}
+
+// runCommand runs the subcommand named by the first argument, if there is one,
+// and exits with its exit code.
+func runCommand(args []string) {
+	if len(args) == 0 {
+		return
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return
+	}
+
+	os.Exit(cmd(args[1:]))
+}
+
+// newFlagSet returns the FlagSet for a subcommand, with a usage message
+// listing its arguments.
+func newFlagSet(name, args, description string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s [flags] %s\n\n%s\n\nFlags:\n", os.Args[0], name, args, description)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// setupCommandLogging logs warnings and errors of a subcommand to stderr, with
+// credential values redacted, so only the command's output ends up on stdout.
+func setupCommandLogging() {
+	if err := logging.Setup(logging.FormatText, log.WarnLevel.String()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+// errNoSource is used when a subcommand needs the default credential source
+// and none is configured.
+var errNoSource = errors.New("no credential source configured, set --credentials-file or MANIFOLD_API_TOKEN")
+
+// sourceFunc returns the credential source to load the credentials of the
+// object with.
+type sourceFunc func(l *log.Entry, obj *manifest.Object) (controller.CredentialSource, error)
+
+// commandSource returns the credential source for a subcommand, configured
+// like the controller's default source.
+func commandSource(ctx context.Context, credentialsFile string) (controller.CredentialSource, error) {
+	src, _, _, err := defaultSource(ctx, &options{credentialsFile: credentialsFile})
+	if err != nil {
+		return nil, err
+	}
+
+	if src == nil {
+		return nil, errNoSource
+	}
+
+	return src, nil
+}
+
+// defaultSources loads the credentials of every object from the given source.
+// ManifoldAccounts can't be resolved without access to the cluster, so objects
+// which reference one are loaded from the default source with a warning.
+func defaultSources(src controller.CredentialSource) sourceFunc {
+	return func(l *log.Entry, obj *manifest.Object) (controller.CredentialSource, error) {
+		if objectAccount(obj) != "" {
+			l.Warn("ManifoldAccounts can't be used by this command, using the default credential source")
+		}
+
+		return src, nil
+	}
+}
+
+// clusterSources loads the credentials of objects which reference a
+// ManifoldAccount through that account, from the namespace of the object like
+// the controller does, and of the other objects from the default source, which
+// can be nil.
+func clusterSources(kc kubernetes.Interface, crds versioned.Interface, src controller.CredentialSource, cf controller.ClientFunc) sourceFunc {
+	return func(l *log.Entry, obj *manifest.Object) (controller.CredentialSource, error) {
+		if account := objectAccount(obj); account != "" {
+			acctSrc, _, err := controller.AccountClient(kc, crds, cf, obj.Meta().Namespace, account)
+			return acctSrc, err
+		}
+
+		if src == nil {
+			return nil, errNoSource
+		}
+
+		return src, nil
+	}
+}
+
+// objectAccount returns the ManifoldAccount the object references, if any.
+func objectAccount(obj *manifest.Object) string {
+	if obj.Project != nil {
+		return obj.Project.Spec.Account
+	}
+
+	return obj.Resource.Spec.Account
+}
+
+// objectLogger validates the object and returns the log entry to load its
+// credentials with.
+func objectLogger(obj *manifest.Object) (*log.Entry, error) {
+	if errs := obj.Validate(); len(errs) > 0 {
+		return nil, errs
+	}
+
+	return log.WithFields(log.Fields{
+		"file": fmt.Sprintf("%s:%d", obj.File, obj.Line),
+		"kind": obj.Kind(),
+		"name": obj.Meta().Name,
+	}), nil
+}
+
+// desiredSecret returns the secret the controller writes for the object.
+func desiredSecret(ctx context.Context, sources sourceFunc, obj *manifest.Object) (*v1.Secret, error) {
+	l, err := objectLogger(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := sources(l, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if obj.Project != nil {
+		return controller.ProjectSecret(ctx, l, src, obj.Project)
+	}
+
+	return controller.ResourceSecret(ctx, l, src, obj.Resource)
+}
+
+// desiredValues returns the credential values the controller loads for the
+// object, before they're converted for the secret type.
+func desiredValues(ctx context.Context, sources sourceFunc, obj *manifest.Object) (map[string][]byte, error) {
+	l, err := objectLogger(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := sources(l, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if obj.Project != nil {
+		return controller.ProjectValues(ctx, l, src, obj.Project)
+	}
+
+	return controller.ResourceValues(ctx, l, src, obj.Resource)
+}
+
+// registerClusterFlags adds the flags to connect to a cluster to a
+// subcommand's FlagSet.
+func registerClusterFlags(fs *flag.FlagSet, o *options) {
+	fs.StringVar(&o.kubeconfig, "kubeconfig", "", "Path to a kubeconfig file. The default kubeconfig is used when empty.")
+	fs.StringVar(&o.context, "context", "", "The kubeconfig context to use.")
+	fs.StringVar(&o.namespace, "namespace", "", "The namespace of the objects. The namespace of the kubeconfig context is used when empty.")
+}
+
+// clusterClients returns the clients for the cluster configured by the
+// options, and the namespace to use.
+func clusterClients(o *options) (*kubernetes.Clientset, *versioned.Clientset, string, error) {
+	cc := o.clientConfig()
+
+	cfg, err := cc.ClientConfig()
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	namespace := o.namespace
+	if namespace == "" {
+		if namespace, _, err = cc.Namespace(); err != nil {
+			return nil, nil, "", err
+		}
+	}
+
+	kc, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	crds, err := versioned.NewForConfig(cfg)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	return kc, crds, namespace, nil
+}
+
+// loadObjects loads the objects for the arguments of a subcommand. Arguments
+// of the form project/<name> or resource/<name> are looked up in the cluster,
+// which is only connected to when there are any. Everything else is loaded as
+// a manifest.
+func loadObjects(args []string, cluster func() (versioned.Interface, string, error)) ([]*manifest.Object, error) {
+	var objects []*manifest.Object
+	var crds versioned.Interface
+	var namespace string
+	for _, arg := range args {
+		if !isObjectRef(arg) {
+			objs, err := manifest.Load(arg)
+			if err != nil {
+				return nil, err
+			}
+
+			objects = append(objects, objs...)
+			continue
+		}
+
+		if crds == nil {
+			var err error
+			if crds, namespace, err = cluster(); err != nil {
+				return nil, err
+			}
+		}
+
+		obj, err := getObject(crds, namespace, arg)
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+// isObjectRef returns whether the argument refers to an object in the cluster
+// rather than a manifest on disk.
+func isObjectRef(arg string) bool {
+	if _, err := os.Stat(arg); err == nil {
+		return false
+	}
+
+	for _, prefix := range []string{"project/", "projects/", "resource/", "resources/"} {
+		if strings.HasPrefix(strings.ToLower(arg), prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getObject gets the Project or Resource a reference such as project/<name>
+// or resource/<name> points to from the cluster.
+func getObject(crds versioned.Interface, namespace, ref string) (*manifest.Object, error) {
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, fmt.Errorf("'%s' is not a reference, use project/<name> or resource/<name>", ref)
+	}
+
+	var err error
+	obj := &manifest.Object{File: ref}
+	switch strings.ToLower(parts[0]) {
+	case "project", "projects":
+		obj.Project, err = crds.ManifoldV1().Projects(namespace).Get(parts[1], metav1.GetOptions{})
+	case "resource", "resources":
+		obj.Resource, err = crds.ManifoldV1().Resources(namespace).Get(parts[1], metav1.GetOptions{})
+	default:
+		return nil, fmt.Errorf("'%s' is not a reference, use project/<name> or resource/<name>", ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
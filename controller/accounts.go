@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/manifoldco/kubernetes-credentials/client/clientset/versioned"
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+// ErrNoAccount is used when a Project or Resource doesn't reference a
+// ManifoldAccount and the controller has not been configured with a default
+// credential source.
+var ErrNoAccount = errors.New("no account referenced and no default credential source configured")
+
+// ClientFunc builds the credential source for the given Manifold API token and
+// team label, usually a Manifold integrations client. The team can be empty.
+type ClientFunc func(token, team string) (CredentialSource, error)
+
+// accountClient is a cached Manifold client for a ManifoldAccount and the team
+// it's bound to. The resource versions are used to detect changes to the
+// account or its Secret, in which case the client is rebuilt.
+type accountClient struct {
+	accountVersion string
+	secretVersion  string
+	client         CredentialSource
+	team           string
+}
+
+// accountClients keeps a Manifold client per ManifoldAccount, keyed by
+// namespace and name.
+type accountClients struct {
+	mu      sync.Mutex
+	clients map[string]*accountClient
+}
+
+func (ac *accountClients) get(key, accountVersion, secretVersion string) *accountClient {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	cached, ok := ac.clients[key]
+	if !ok || cached.accountVersion != accountVersion || cached.secretVersion != secretVersion {
+		return nil
+	}
+
+	return cached
+}
+
+func (ac *accountClients) set(key, accountVersion, secretVersion string, client CredentialSource, team string) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	if ac.clients == nil {
+		ac.clients = map[string]*accountClient{}
+	}
+
+	ac.clients[key] = &accountClient{
+		accountVersion: accountVersion,
+		secretVersion:  secretVersion,
+		client:         client,
+		team:           team,
+	}
+}
+
+func (ac *accountClients) forget(key string) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	delete(ac.clients, key)
+}
+
+// watchAccounts keeps a cache of the ManifoldAccounts and the Secrets they
+// reference, so loading the client of an account doesn't call the Kubernetes
+// API. The cached client of an account is dropped once it's deleted.
+func (c *Controller) watchAccounts(ctx context.Context) error {
+	c.watch(ctx, c.informers.Manifold().V1().ManifoldAccounts().Informer(), cache.ResourceEventHandlerFuncs{
+		DeleteFunc: c.onAccountDelete,
+	})
+	c.run(ctx, c.kinformers.Core().V1().Secrets().Informer())
+	return nil
+}
+
+func (c *Controller) onAccountDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	acct, ok := obj.(*primitives.ManifoldAccount)
+	if !ok {
+		return
+	}
+
+	c.clients.forget(acct.Namespace + "/" + acct.Name)
+}
+
+// client returns the credential source that should be used to load
+// credentials for an object in the given namespace and the team it's bound
+// to. If no account is referenced, the default source is used. Accounts are always looked up in
+// the namespace of the object referencing them, so one namespace can't use the
+// token of another. Accounts and their Secrets are read from the informer
+// caches. All sources share the throttle of the controller. With fresh set,
+// the cached client of an account is rebuilt.
+func (c *Controller) client(namespace, account string, fresh bool) (CredentialSource, string, error) {
+	if account == "" {
+		mc, team := c.defaultClient()
+		if mc == nil {
+			return nil, "", ErrNoAccount
+		}
+
+		return c.instrumented(mc), team, nil
+	}
+
+	acct, err := c.accounts.ManifoldAccounts(namespace).Get(account)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not get account '%s': %s", account, err)
+	}
+
+	if acct.Spec == nil || acct.Spec.SecretName == "" {
+		return nil, "", fmt.Errorf("account '%s' does not reference a secret", account)
+	}
+
+	secret, err := c.secrets.Secrets(namespace).Get(acct.Spec.SecretName)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not get secret '%s' for account '%s': %s", acct.Spec.SecretName, account, err)
+	}
+
+	key := namespace + "/" + account
+	if cached := c.clients.get(key, acct.ResourceVersion, secret.ResourceVersion); cached != nil && !fresh {
+		return c.instrumented(cached.client), cached.team, nil
+	}
+
+	cl, team, err := newAccountClient(c.newClient, acct, secret)
+	if err != nil {
+		return nil, "", err
+	}
+
+	c.clients.set(key, acct.ResourceVersion, secret.ResourceVersion, cl, team)
+	return c.instrumented(cl), team, nil
+}
+
+// AccountClient returns the credential source of the named ManifoldAccount in
+// the given namespace and the team it's bound to. Unlike the controller, which
+// reads them from its informer caches, it gets the account and its Secret from
+// the API, for the subcommands which only load credentials once.
+func AccountClient(kc kubernetes.Interface, crds versioned.Interface, cf ClientFunc, namespace, account string) (CredentialSource, string, error) {
+	acct, err := crds.ManifoldV1().ManifoldAccounts(namespace).Get(account, metav1.GetOptions{})
+	if err != nil {
+		return nil, "", fmt.Errorf("could not get account '%s': %s", account, err)
+	}
+
+	if acct.Spec == nil || acct.Spec.SecretName == "" {
+		return nil, "", fmt.Errorf("account '%s' does not reference a secret", account)
+	}
+
+	secret, err := kc.CoreV1().Secrets(namespace).Get(acct.Spec.SecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, "", fmt.Errorf("could not get secret '%s' for account '%s': %s", acct.Spec.SecretName, account, err)
+	}
+
+	return newAccountClient(cf, acct, secret)
+}
+
+// newAccountClient builds the credential source of the account with the token
+// and team in the given Secret.
+func newAccountClient(cf ClientFunc, acct *primitives.ManifoldAccount, secret *v1.Secret) (CredentialSource, string, error) {
+	token, ok := secret.Data[acct.Spec.SecretTokenKey()]
+	if !ok || len(token) == 0 {
+		return nil, "", fmt.Errorf("secret '%s' for account '%s' has no '%s' key", secret.Name, acct.Name, acct.Spec.SecretTokenKey())
+	}
+
+	team := string(secret.Data[acct.Spec.SecretTeamKey()])
+	cl, err := cf(string(token), team)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not create client for account '%s': %s", acct.Name, err)
+	}
+
+	return cl, team, nil
+}
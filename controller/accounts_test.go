@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestClient_account(t *testing.T) {
+	acct, acctSecret := accountObjects()
+
+	tcs := []struct {
+		scenario string
+		deleted  interface{}
+	}{
+		{scenario: "forgets the client of a deleted account", deleted: acct},
+		{scenario: "forgets the client of a tombstoned account", deleted: cache.DeletedFinalStateUnknown{Key: "default/other", Obj: acct}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			c, kc, crds := newTestController([]runtime.Object{acctSecret}, []runtime.Object{acct})
+
+			if _, _, err := c.client("default", "other", false); err != nil {
+				t.Fatalf("expected no error getting the client, got %q", err)
+			}
+			if cl := c.clients.get("default/other", acct.ResourceVersion, acctSecret.ResourceVersion); cl == nil {
+				t.Fatal("expected the client of the account to be cached")
+			}
+			if actions := len(kc.Actions()) + len(crds.Actions()); actions != 0 {
+				t.Errorf("expected the account to be loaded from the caches, got %d API calls", actions)
+			}
+
+			c.onAccountDelete(tc.deleted)
+
+			if cl := c.clients.get("default/other", acct.ResourceVersion, acctSecret.ResourceVersion); cl != nil {
+				t.Error("expected the client of the account to be forgotten")
+			}
+		})
+	}
+
+	t.Run("fails for an account that isn't cached", func(t *testing.T) {
+		c, _, _ := newTestController(nil, nil)
+
+		if _, _, err := c.client("default", "other", false); err == nil {
+			t.Error("expected an error for an unknown account")
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+// AdoptionPolicy decides whether the controller takes over an existing secret
+// which isn't owned by any controller, for example one that was created by
+// hand. Secrets owned by another controller are never taken over.
+type AdoptionPolicy string
+
+// The supported adoption policies.
+const (
+	// AdoptNever leaves unowned secrets alone and reports a conflict.
+	AdoptNever AdoptionPolicy = "never"
+
+	// AdoptIfLabeled only takes over unowned secrets with the adopt
+	// annotation set to "true".
+	AdoptIfLabeled AdoptionPolicy = "ifLabeled"
+
+	// AdoptAlways takes over any unowned secret.
+	AdoptAlways AdoptionPolicy = "always"
+)
+
+// ParseAdoptionPolicy returns the adoption policy with the given name. The
+// empty string is the default, AdoptNever.
+func ParseAdoptionPolicy(s string) (AdoptionPolicy, error) {
+	switch p := AdoptionPolicy(s); p {
+	case "":
+		return AdoptNever, nil
+	case AdoptNever, AdoptIfLabeled, AdoptAlways:
+		return p, nil
+	default:
+		return "", fmt.Errorf("adoption policy '%s' not supported, use %s, %s or %s", s, AdoptNever, AdoptIfLabeled, AdoptAlways)
+	}
+}
+
+// secretConflictError is returned when an existing secret can't be written
+// because the controller doesn't own it and isn't allowed to take it over.
+type secretConflictError struct {
+	reason  string
+	message string
+}
+
+func (e *secretConflictError) Error() string {
+	return e.message
+}
+
+// checkAdoption returns an error if the controller isn't allowed to write the
+// existing secret for the object with the given kind and metadata. Secrets the
+// object already controls can always be written, as can secrets which were
+// retained when an object with the same kind and name was deleted.
+func (c *Controller) checkAdoption(l *log.Entry, existing *v1.Secret, meta *metav1.ObjectMeta, gkv schema.GroupVersionKind) error {
+	if ref := metav1.GetControllerOf(existing); ref != nil {
+		if ref.UID == meta.UID {
+			return nil
+		}
+
+		return &secretConflictError{
+			reason:  "OwnedByOtherController",
+			message: fmt.Sprintf("secret '%s' is controlled by %s '%s'", existing.Name, ref.Kind, ref.Name),
+		}
+	}
+
+	if existing.Annotations[primitives.AnnotationOrphanedFrom] == orphanedFrom(gkv, meta) {
+		l.Info("adopting secret retained from a deleted object")
+		return nil
+	}
+
+	switch c.adoption {
+	case AdoptAlways:
+	case AdoptIfLabeled:
+		if existing.Annotations[primitives.AnnotationAdopt] != "true" {
+			return &secretConflictError{
+				reason:  "NotAdoptable",
+				message: fmt.Sprintf("secret '%s' already exists, set the %s annotation to \"true\" on it to let the controller take it over", existing.Name, primitives.AnnotationAdopt),
+			}
+		}
+	default:
+		return &secretConflictError{
+			reason:  "AlreadyExists",
+			message: fmt.Sprintf("secret '%s' already exists and isn't managed by the controller", existing.Name),
+		}
+	}
+
+	l.WithField("adoption_policy", string(c.adoption)).Info("adopting existing secret")
+	return nil
+}
+
+// withControllerRef returns the owner references of an existing secret with
+// its controller reference replaced by the given one. Other owners are kept.
+func withControllerRef(refs []metav1.OwnerReference, ref metav1.OwnerReference) []metav1.OwnerReference {
+	owners := []metav1.OwnerReference{ref}
+	for _, r := range refs {
+		if r.UID == ref.UID || (r.Controller != nil && *r.Controller) {
+			continue
+		}
+		owners = append(owners, r)
+	}
+
+	return owners
+}
+
+// conflictStatus updates the SecretConflict condition of the given status and
+// reports whether the status changed.
+func conflictStatus(status *primitives.Status, err error) bool {
+	conflict, ok := err.(*secretConflictError)
+	if !ok {
+		return status.RemoveCondition(primitives.ConditionSecretConflict)
+	}
+
+	return status.SetCondition(primitives.Condition{
+		Type:    primitives.ConditionSecretConflict,
+		Status:  v1.ConditionTrue,
+		Reason:  conflict.reason,
+		Message: conflict.message,
+	})
+}
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"testing"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+func TestParseAdoptionPolicy(t *testing.T) {
+	tcs := []struct {
+		value    string
+		expected AdoptionPolicy
+		err      bool
+	}{
+		{value: "", expected: AdoptNever},
+		{value: "never", expected: AdoptNever},
+		{value: "ifLabeled", expected: AdoptIfLabeled},
+		{value: "always", expected: AdoptAlways},
+		{value: "sometimes", err: true},
+	}
+
+	for _, tc := range tcs {
+		p, err := ParseAdoptionPolicy(tc.value)
+		if tc.err != (err != nil) {
+			t.Errorf("expected error for %q to be %t, got %v", tc.value, tc.err, err)
+		}
+		if p != tc.expected {
+			t.Errorf("expected %q to eq %q, got %q", tc.value, tc.expected, p)
+		}
+	}
+}
+
+func TestAdoption(t *testing.T) {
+	annotated := existingSecret(map[string]string{"USERNAME": "manual"})
+	annotated.Annotations = map[string]string{primitives.AnnotationAdopt: "true"}
+
+	otherOwner := existingSecret(map[string]string{"USERNAME": "manual"})
+	otherOwner.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: "apps/v1",
+		Kind:       "ReplicaSet",
+		Name:       "other",
+		UID:        "other-uid",
+		Controller: &[]bool{true}[0],
+	}}
+
+	tcs := []struct {
+		scenario string
+		policy   AdoptionPolicy
+		secret   *v1.Secret
+		adopted  bool
+		reason   string
+	}{
+		{
+			scenario: "never adopts an unowned secret",
+			policy:   AdoptNever,
+			secret:   existingSecret(map[string]string{"USERNAME": "manual"}),
+			reason:   "AlreadyExists",
+		},
+		{
+			scenario: "doesn't adopt an unannotated secret if labeled",
+			policy:   AdoptIfLabeled,
+			secret:   existingSecret(map[string]string{"USERNAME": "manual"}),
+			reason:   "NotAdoptable",
+		},
+		{
+			scenario: "adopts an annotated secret if labeled",
+			policy:   AdoptIfLabeled,
+			secret:   annotated,
+			adopted:  true,
+		},
+		{
+			scenario: "always adopts an unowned secret",
+			policy:   AdoptAlways,
+			secret:   existingSecret(map[string]string{"USERNAME": "manual"}),
+			adopted:  true,
+		},
+		{
+			scenario: "never takes over a secret controlled by someone else",
+			policy:   AdoptAlways,
+			secret:   otherOwner,
+			reason:   "OwnedByOtherController",
+		},
+		{
+			scenario: "updates a secret it already controls",
+			policy:   AdoptNever,
+			secret:   controlledSecret("project-uid", map[string]string{"USERNAME": "old"}),
+			adopted:  true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			project := testProject(&primitives.ProjectSpec{
+				Name: "production",
+				Resources: []*primitives.ResourceSpec{
+					{Name: "db", Credentials: []*primitives.CredentialSpec{{Key: "USERNAME"}}},
+				},
+			})
+			c, kc, crds := newTestController([]runtime.Object{tc.secret}, []runtime.Object{project})
+			c.adoption = tc.policy
+
+			c.createOrUpdateProject(project)
+
+			updated, err := crds.ManifoldV1().Projects("default").Get("creds", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("expected no error getting the project, got %q", err)
+			}
+			cond := updated.Status.Condition(primitives.ConditionSecretConflict)
+
+			if tc.adopted {
+				expectSecret(t, kc, "project-uid", map[string]string{"USERNAME": "prod-user"})
+				expectReady(t, &updated.Status, v1.ConditionTrue)
+				if cond != nil {
+					t.Errorf("expected no %s condition, got %v", primitives.ConditionSecretConflict, cond)
+				}
+				return
+			}
+
+			secret, err := kc.CoreV1().Secrets("default").Get("creds", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("expected no error getting the secret, got %q", err)
+			}
+			if got := string(secret.Data["USERNAME"]); got != "manual" {
+				t.Errorf("expected USERNAME to eq %q, got %q", "manual", got)
+			}
+
+			expectReady(t, &updated.Status, v1.ConditionFalse)
+			if cond == nil || cond.Reason != tc.reason {
+				t.Errorf("expected a %s condition with reason %q, got %v", primitives.ConditionSecretConflict, tc.reason, cond)
+			}
+		})
+	}
+}
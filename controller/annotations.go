@@ -0,0 +1,52 @@
+package controller
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+// isPaused reports whether reconciling the object has been paused.
+func isPaused(meta *metav1.ObjectMeta) bool {
+	return meta.Annotations[primitives.AnnotationPaused] == "true"
+}
+
+// pausedStatus updates the Paused condition of the given status and reports
+// whether the status changed.
+func pausedStatus(status *primitives.Status, paused bool) bool {
+	if !paused {
+		return status.RemoveCondition(primitives.ConditionPaused)
+	}
+
+	return status.SetCondition(primitives.Condition{
+		Type:    primitives.ConditionPaused,
+		Status:  v1.ConditionTrue,
+		Reason:  "Paused",
+		Message: "reconciling is paused by the " + primitives.AnnotationPaused + " annotation",
+	})
+}
+
+// refreshRequested returns the value of the refresh-at annotation if that
+// refresh hasn't been done yet. The value of the last successful refresh is
+// kept in the status, so a refresh is retried until it succeeds and isn't
+// repeated on every resync or restart.
+func refreshRequested(meta *metav1.ObjectMeta, status *primitives.Status) string {
+	refresh := meta.Annotations[primitives.AnnotationRefreshAt]
+	if refresh == status.RefreshedAt {
+		return ""
+	}
+
+	return refresh
+}
+
+// refreshedStatus records the refresh as done and reports whether the status
+// changed.
+func refreshedStatus(status *primitives.Status, refresh string) bool {
+	if refresh == "" || status.RefreshedAt == refresh {
+		return false
+	}
+
+	status.RefreshedAt = refresh
+	return true
+}
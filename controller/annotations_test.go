@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+func TestRefreshRequested(t *testing.T) {
+	tcs := []struct {
+		scenario    string
+		annotation  string
+		refreshedAt string
+		expected    string
+	}{
+		{"without the annotation", "", "", ""},
+		{"with a new refresh", "2018-11-01T10:00:00Z", "", "2018-11-01T10:00:00Z"},
+		{"with a refresh that was done", "2018-11-01T10:00:00Z", "2018-11-01T10:00:00Z", ""},
+		{"with a newer refresh", "2018-11-02T10:00:00Z", "2018-11-01T10:00:00Z", "2018-11-02T10:00:00Z"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			meta := metav1.ObjectMeta{Annotations: map[string]string{}}
+			if tc.annotation != "" {
+				meta.Annotations[primitives.AnnotationRefreshAt] = tc.annotation
+			}
+			status := primitives.Status{RefreshedAt: tc.refreshedAt}
+
+			if refresh := refreshRequested(&meta, &status); refresh != tc.expected {
+				t.Errorf("Expected refresh to be '%s', got '%s'", tc.expected, refresh)
+			}
+		})
+	}
+}
+
+func TestPausedStatus(t *testing.T) {
+	var status primitives.Status
+
+	if !pausedStatus(&status, true) {
+		t.Error("Expected pausing to change the status")
+	}
+	if c := status.Condition(primitives.ConditionPaused); c == nil {
+		t.Error("Expected the Paused condition to be set")
+	}
+	if pausedStatus(&status, true) {
+		t.Error("Expected pausing again not to change the status")
+	}
+	if !pausedStatus(&status, false) {
+		t.Error("Expected resuming to change the status")
+	}
+	if c := status.Condition(primitives.ConditionPaused); c != nil {
+		t.Error("Expected the Paused condition to be removed")
+	}
+}
@@ -1,25 +1,37 @@
 package controller
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/fields"
-	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
 
 	"github.com/manifoldco/go-manifold/integrations"
+	"github.com/manifoldco/kubernetes-credentials/client/clientset/versioned"
+	"github.com/manifoldco/kubernetes-credentials/client/informers/externalversions"
+	listers "github.com/manifoldco/kubernetes-credentials/client/listers/manifold/v1"
 	"github.com/manifoldco/kubernetes-credentials/crd"
+	"github.com/manifoldco/kubernetes-credentials/logging"
 	"github.com/manifoldco/kubernetes-credentials/primitives"
+	"github.com/manifoldco/kubernetes-credentials/snapshot"
+	"github.com/manifoldco/kubernetes-credentials/throttle"
 )
 
 var (
@@ -27,22 +39,189 @@ var (
 	resourceControllerKind = crd.SchemeGroupVersion.WithKind("Resource")
 )
 
+// Defaults for the controller options.
+const (
+	defaultResyncPeriod        = 10 * time.Second
+	defaultCacheSyncTimeout    = 2 * time.Minute
+	defaultShutdownGracePeriod = 30 * time.Second
+)
+
+// Options configure the controller.
+type Options struct {
+	// Namespace limits the Projects and Resources that are watched to a
+	// single namespace. All namespaces are watched when empty.
+	Namespace string
+
+	// ResyncPeriod is how often all Projects and Resources are reconciled.
+	ResyncPeriod time.Duration
+
+	// CacheSyncTimeout is how long Run waits for the informer caches to sync
+	// before giving up.
+	CacheSyncTimeout time.Duration
+
+	// ShutdownGracePeriod is how long Run waits for in-flight reconciles to
+	// finish once it's being stopped.
+	ShutdownGracePeriod time.Duration
+
+	// Snapshot keeps the last known good credentials which are served when
+	// the credential source is unavailable. Optional.
+	Snapshot *snapshot.Snapshot
+
+	// Throttle is the rate limiter and circuit breaker of the Manifold API
+	// requests. It only guards the clients which use its Transport; the
+	// controller reports its state. Optional.
+	Throttle *throttle.Throttle
+
+	// Rollout restarts the Deployments, StatefulSets and DaemonSets that use a
+	// secret whenever its data changes.
+	Rollout bool
+
+	// AdoptionPolicy decides whether existing secrets which aren't owned by
+	// any controller are taken over. Defaults to AdoptNever.
+	AdoptionPolicy AdoptionPolicy
+
+	// Team is the Manifold team the default credential source is bound to.
+	// Projects and Resources using the default source can't request another
+	// team and credential policies are checked against this one.
+	Team string
+
+	// DeletionPolicy is what happens to the secret of a deleted Project or
+	// Resource which doesn't set its own spec.deletionPolicy. Defaults to
+	// primitives.DeletionPolicyDelete.
+	DeletionPolicy string
+}
+
 // Controller is the kubernetes controller that handles syncing Manifold
 // credentials into kubernetes secrets.
 type Controller struct {
-	kc        *kubernetes.Clientset
-	rc        *rest.RESTClient
-	mc        *integrations.Client
-	namespace string
+	kc         kubernetes.Interface
+	crds       versioned.Interface
+	informers  externalversions.SharedInformerFactory
+	kinformers informers.SharedInformerFactory
+
+	cacheSyncTimeout    time.Duration
+	shutdownGracePeriod time.Duration
+	snapshot            *snapshot.Snapshot
+	throttle            *throttle.Throttle
+	rollout             bool
+	adoption            AdoptionPolicy
+
+	defaultDeletionPolicy string
+
+	// queue holds the Projects and Resources waiting to be reconciled.
+	queue *workqueue.Type
+
+	// inflight tracks the reconciles that are in progress so we can wait for
+	// them on shutdown. Once draining, no new reconciles are started.
+	inflightMu sync.Mutex
+	inflight   sync.WaitGroup
+	draining   bool
+
+	mu   sync.RWMutex
+	mc   CredentialSource
+	team string
+
+	newClient ClientFunc
+	clients   accountClients
+	accounts  listers.ManifoldAccountLister
+	secrets   corelisters.SecretLister
+
+	policies       listers.CredentialPolicyLister
+	policiesSynced cache.InformerSynced
+	namespaces     corelisters.NamespaceLister
+
+	projects  listers.ProjectLister
+	resources listers.ResourceLister
+
+	// These are used for the health checks. The API call times are unix
+	// nanoseconds and are accessed atomically.
+	started        time.Time
+	synced         []cache.InformerSynced
+	running        int32
+	lastAPISuccess int64
+	lastAPIFailure int64
 }
 
-// New returns a new controller
-func New(kc *kubernetes.Clientset, rc *rest.RESTClient, mc *integrations.Client) *Controller {
-	return &Controller{kc: kc, rc: rc, mc: mc}
+// New returns a new controller. The given credential source is used for
+// Projects and Resources that don't reference a ManifoldAccount and can be
+// nil, in which case every object is required to reference an account. The
+// ClientFunc is used to build Manifold clients for ManifoldAccounts.
+func New(kc kubernetes.Interface, crds versioned.Interface, mc CredentialSource, cf ClientFunc, opts Options) *Controller {
+	if opts.Throttle != nil {
+		watchBreaker(opts.Throttle.Breaker)
+	}
+
+	resync := durationOrDefault(opts.ResyncPeriod, defaultResyncPeriod)
+	crdInformers := externalversions.NewFilteredSharedInformerFactory(crds, resync, opts.Namespace, nil)
+	kinformers := informers.NewFilteredSharedInformerFactory(kc, resync, opts.Namespace, nil)
+
+	return &Controller{
+		kc:                  kc,
+		crds:                crds,
+		informers:           crdInformers,
+		kinformers:          kinformers,
+		queue:               workqueue.NewNamed("credentials"),
+		policies:            crdInformers.Manifold().V1().CredentialPolicies().Lister(),
+		projects:            crdInformers.Manifold().V1().Projects().Lister(),
+		resources:           crdInformers.Manifold().V1().Resources().Lister(),
+		accounts:            crdInformers.Manifold().V1().ManifoldAccounts().Lister(),
+		secrets:             kinformers.Core().V1().Secrets().Lister(),
+		namespaces:          kinformers.Core().V1().Namespaces().Lister(),
+		mc:                  mc,
+		team:                opts.Team,
+		newClient:           cf,
+		cacheSyncTimeout:    durationOrDefault(opts.CacheSyncTimeout, defaultCacheSyncTimeout),
+		shutdownGracePeriod: durationOrDefault(opts.ShutdownGracePeriod, defaultShutdownGracePeriod),
+		snapshot:            opts.Snapshot,
+		throttle:            opts.Throttle,
+		rollout:             opts.Rollout,
+		adoption:            opts.AdoptionPolicy,
+
+		defaultDeletionPolicy: opts.DeletionPolicy,
+	}
 }
 
-// Run runs this controller
+// SetClient replaces the default credential source and the team it's bound
+// to. Reconciles that are already in progress finish with the previous source.
+func (c *Controller) SetClient(mc CredentialSource, team string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.mc = mc
+	c.team = team
+}
+
+func (c *Controller) defaultClient() (CredentialSource, string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.mc, c.team
+}
+
+// Run runs this controller until the context is cancelled. It returns an
+// error if the informer caches don't sync within the configured timeout. The
+// events received in the meantime are queued and only reconciled once the
+// caches have synced. Once the context is cancelled, no new reconciles are
+// started and Run waits for the ones in progress to finish within the shutdown
+// grace period.
 func (c *Controller) Run(ctx context.Context) error {
+	c.started = time.Now()
+
+	if err := c.watchPolicies(ctx); err != nil {
+		log.WithError(err).Error("could not register policy watcher")
+		return err
+	}
+
+	if err := c.watchAccounts(ctx); err != nil {
+		log.WithError(err).Error("could not register account watcher")
+		return err
+	}
+
+	if err := c.watchWorkloads(ctx); err != nil {
+		log.WithError(err).Error("could not register workload watcher")
+		return err
+	}
+
 	if err := c.watchProjects(ctx); err != nil {
 		log.WithError(err).Error("could not register project watcher")
 		return err
@@ -53,159 +232,634 @@ func (c *Controller) Run(ctx context.Context) error {
 		return err
 	}
 
-	return nil
+	if err := c.waitForCacheSync(ctx); err != nil {
+		c.queue.ShutDown()
+		return err
+	}
+	log.Info("Caches synced, controller is running")
+
+	for i := 0; i < workers; i++ {
+		go c.work()
+	}
+
+	<-ctx.Done()
+	return c.drain()
 }
 
-// watch configures and runs a watcher for the given resource type. We use this
-// to listen for changes on both projects and resources.
-func (c *Controller) watch(ctx context.Context, resource string, obj runtime.Object, handler cache.ResourceEventHandler) error {
-	source := cache.NewListWatchFromClient(c.rc, resource, c.namespace, fields.Everything())
+// waitForCacheSync blocks until all informer caches have synced.
+func (c *Controller) waitForCacheSync(ctx context.Context) error {
+	syncCtx, cancel := context.WithTimeout(ctx, c.cacheSyncTimeout)
+	defer cancel()
 
-	resyncPeriod := 10 * time.Second
-	_, controller := cache.NewInformer(source, obj, resyncPeriod, handler)
+	c.mu.RLock()
+	synced := c.synced
+	c.mu.RUnlock()
+
+	if !cache.WaitForCacheSync(syncCtx.Done(), synced...) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		return fmt.Errorf("timed out after %s waiting for the informer caches to sync", c.cacheSyncTimeout)
+	}
 
-	go controller.Run(ctx.Done())
 	return nil
+}
 
+// track registers a reconcile as in progress. It returns false when the
+// controller is shutting down, in which case the reconcile should be skipped.
+func (c *Controller) track() bool {
+	c.inflightMu.Lock()
+	defer c.inflightMu.Unlock()
+
+	if c.draining {
+		return false
+	}
+
+	c.inflight.Add(1)
+	return true
+}
+
+// drain stops new reconciles from starting and waits for the ones in progress
+// to finish within the shutdown grace period.
+func (c *Controller) drain() error {
+	c.inflightMu.Lock()
+	c.draining = true
+	c.inflightMu.Unlock()
+	c.queue.ShutDown()
+
+	done := make(chan struct{})
+	go func() {
+		c.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(c.shutdownGracePeriod):
+		return fmt.Errorf("in-flight reconciles did not finish within %s", c.shutdownGracePeriod)
+	}
+}
+
+func durationOrDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+
+	return d
+}
+
+// watch registers the handler with the shared informer and runs it. We use
+// this to listen for changes on both projects and resources.
+func (c *Controller) watch(ctx context.Context, informer cache.SharedIndexInformer, handler cache.ResourceEventHandler) {
+	informer.AddEventHandler(handler)
+	c.run(ctx, informer)
+}
+
+// run runs the informer in the background and keeps track of it for the
+// health checks.
+func (c *Controller) run(ctx context.Context, informer cache.SharedIndexInformer) {
+	c.mu.Lock()
+	c.synced = append(c.synced, informer.HasSynced)
+	c.mu.Unlock()
+
+	atomic.AddInt32(&c.running, 1)
+	go func() {
+		defer atomic.AddInt32(&c.running, -1)
+		informer.Run(ctx.Done())
+	}()
+}
+
+// watchPolicies keeps a cache of the cluster scoped CredentialPolicies which
+// is used to verify Projects and Resources before loading their credentials,
+// and of the namespaces their label selectors are matched against.
+func (c *Controller) watchPolicies(ctx context.Context) error {
+	informer := c.informers.Manifold().V1().CredentialPolicies().Informer()
+	c.policiesSynced = informer.HasSynced
+
+	c.run(ctx, informer)
+	c.run(ctx, c.kinformers.Core().V1().Namespaces().Informer())
+	return nil
 }
 
 func (c *Controller) watchProjects(ctx context.Context) error {
-	return c.watch(ctx, primitives.CRDProjectsPlural, &primitives.Project{}, cache.ResourceEventHandlerFuncs{
+	c.watch(ctx, c.informers.Manifold().V1().Projects().Informer(), cache.ResourceEventHandlerFuncs{
 		AddFunc:    c.onProjectAdd,
 		UpdateFunc: c.onProjectUpdate,
 		DeleteFunc: c.onProjectDelete,
 	})
+	return nil
 }
 
 func (c *Controller) watchResources(ctx context.Context) error {
-	return c.watch(ctx, primitives.CRDResourcesPlural, &primitives.Resource{}, cache.ResourceEventHandlerFuncs{
+	c.watch(ctx, c.informers.Manifold().V1().Resources().Informer(), cache.ResourceEventHandlerFuncs{
 		AddFunc:    c.onResourceAdd,
 		UpdateFunc: c.onResourceUpdate,
 		DeleteFunc: c.onResourceDelete,
 	})
+	return nil
 }
 
-func (c *Controller) onProjectAdd(obj interface{})         { c.createOrUpdateProject(obj) }
-func (c *Controller) onProjectUpdate(old, new interface{}) { c.createOrUpdateProject(new) }
+// countObjects reports the number of Projects and Resources in the caches.
+func (c *Controller) countObjects() {
+	if projects, err := c.projects.List(labels.Everything()); err == nil {
+		managedObjects.WithLabelValues(kindProject).Set(float64(len(projects)))
+	}
+
+	if resources, err := c.resources.List(labels.Everything()); err == nil {
+		managedObjects.WithLabelValues(kindResource).Set(float64(len(resources)))
+	}
+}
+
+func (c *Controller) onProjectAdd(obj interface{}) {
+	c.countObjects()
+	c.enqueue(kindProject, obj)
+}
+
+func (c *Controller) onProjectUpdate(old, new interface{}) { c.enqueue(kindProject, new) }
+
+func (c *Controller) onProjectDelete(obj interface{}) { c.enqueueDeleted(kindProject, obj) }
 
 func (c *Controller) createOrUpdateProject(obj interface{}) {
-	project := obj.(*primitives.Project)
+	if !c.track() {
+		return
+	}
+	defer c.inflight.Done()
+
+	project := obj.(*primitives.Project).DeepCopy()
 	ctx := context.Background()
+
 	l := log.WithFields(log.Fields{
-		"crd_name":      project.Name,
-		"crd_namespace": project.Namespace,
-		"project":       project.Spec.Name,
-		"team":          project.Spec.Team,
-		"type":          project.Spec.Type,
+		logging.ReconcileIDField: logging.NewReconcileID(),
+		"crd_name":               project.Name,
+		"crd_namespace":          project.Namespace,
+		"project":                project.Spec.Name,
+		"team":                   project.Spec.Team,
+		"account":                project.Spec.Account,
+		"type":                   project.Spec.Type,
 	})
 
-	creds, err := c.mc.GetResourcesCredentialValues(ctx, &project.Spec.Name, project.Spec.ManifoldPrimitive().Resources)
+	if project.DeletionTimestamp != nil {
+		c.finalize(l, project, &project.ObjectMeta, projectControllerKind, project.Spec.DeletionPolicy, project.Spec.MergeStrategy())
+		return
+	}
+	c.ensureFinalizer(l, project, &project.ObjectMeta, project.Spec.DeletionPolicy, project.Spec.MergeStrategy())
+
+	paused := isPaused(&project.ObjectMeta)
+	c.updateStatus(l, project, &project.Status, func(s *primitives.Status) bool {
+		return pausedStatus(s, paused)
+	})
+	if paused {
+		l.Debug("project is paused, skipping")
+		return
+	}
+
+	var synced bool
+	defer observeReconcile(kindProject, &project.ObjectMeta, time.Now(), &synced)
+	defer func() {
+		c.updateStatus(l, project, &project.Status, func(s *primitives.Status) bool {
+			return readyStatus(s, synced)
+		})
+	}()
+
+	err := primitives.ValidateDeletionPolicy(project.Spec.DeletionPolicy)
+	c.updateStatus(l, project, &project.Status, func(s *primitives.Status) bool {
+		return specStatus(s, err)
+	})
 	if err != nil {
-		l.WithError(err).Error("could not get project credentials")
+		l.WithError(err).Error("invalid project spec")
 		return
 	}
 
-	cmap, err := integrations.FlattenResourcesCredentialValues(creds)
+	refresh := refreshRequested(&project.ObjectMeta, &project.Status)
+	if refresh != "" {
+		l = l.WithField("refresh_at", refresh)
+		l.Info("refresh requested, bypassing caches")
+	}
+
+	mc, team, err := c.client(project.Namespace, project.Spec.Account, refresh != "")
 	if err != nil {
-		l.WithError(err).Error("could not flatten project credentials")
+		l.WithError(err).Error("could not get manifold client")
 		return
 	}
 
-	// determine if we need to decode values or not
-	encodingKeys := map[string]string{}
-	for _, resource := range project.Spec.Resources {
-		encodingResourceKeys(resource, encodingKeys)
+	err = c.checkPolicy(l, project.Namespace, team, project.Spec.CredentialRequest())
+	c.updateStatus(l, project, &project.Status, func(s *primitives.Status) bool {
+		return policyStatus(s, err)
+	})
+	if err != nil {
+		l.WithError(err).Error("project not allowed by credential policy")
+		return
+	}
+
+	cmap, err := projectCredentials(ctx, mc, project.Spec)
+	cmap, cached, err := c.withSnapshot(l, refresh != "", snapshotKey(kindProject, project.Namespace, project.Spec.Account, project.Spec.ManifoldPrimitive()), cmap, err)
+	c.updateStatus(l, project, &project.Status, func(s *primitives.Status) bool {
+		return cacheStatus(s, cached)
+	})
+	if err != nil {
+		l.WithError(err).Error("could not get project credentials")
+		return
 	}
+	defer logging.Redact(mapValues(cmap)...)()
 
-	secretData := decodedByteMap(cmap, encodingKeys)
-	c.createOrUpdateSecret(&project.ObjectMeta, secretData, project.Spec.SecretType(), projectControllerKind)
+	secretData := projectSecretData(l, project.Spec, cmap)
+	defer logging.RedactBytes(secretData)()
+
+	synced, err = c.createOrUpdateSecret(l, &project.ObjectMeta, secretData, project.Spec.SecretType(), projectControllerKind, project.Spec.MergeStrategy(), refresh != "")
+	c.updateStatus(l, project, &project.Status, func(s *primitives.Status) bool {
+		return conflictStatus(s, err)
+	})
+	if synced {
+		c.updateStatus(l, project, &project.Status, func(s *primitives.Status) bool {
+			return refreshedStatus(s, refresh)
+		})
+	}
 }
 
-func (c *Controller) onProjectDelete(obj interface{}) {
-	project := obj.(*primitives.Project)
-	if err := c.kc.Core().Secrets(project.Namespace).Delete(project.Name, &metav1.DeleteOptions{}); err != nil {
-		log.WithError(err).Error("issue deleting the project")
+func (c *Controller) deleteProject(project *primitives.Project) {
+	if !c.track() {
+		return
+	}
+	defer c.inflight.Done()
+
+	c.countObjects()
+	forgetObject(kindProject, &project.ObjectMeta)
+
+	l := log.WithFields(log.Fields{
+		logging.ReconcileIDField: logging.NewReconcileID(),
+		"crd_name":               project.Name,
+		"crd_namespace":          project.Namespace,
+	})
+	if err := c.applyDeletionPolicy(l, &project.ObjectMeta, projectControllerKind, project.Spec.DeletionPolicy, project.Spec.MergeStrategy()); err != nil {
+		l.WithError(err).Error("issue deleting the project")
 	}
 }
 
-func (c *Controller) onResourceAdd(obj interface{})         { c.createOrUpdateResource(obj) }
-func (c *Controller) onResourceUpdate(old, new interface{}) { c.createOrUpdateResource(new) }
+func (c *Controller) onResourceAdd(obj interface{}) {
+	c.countObjects()
+	c.enqueue(kindResource, obj)
+}
+
+func (c *Controller) onResourceUpdate(old, new interface{}) { c.enqueue(kindResource, new) }
+
+func (c *Controller) onResourceDelete(obj interface{}) { c.enqueueDeleted(kindResource, obj) }
 
 func (c *Controller) createOrUpdateResource(obj interface{}) {
-	resource := obj.(*primitives.Resource)
+	if !c.track() {
+		return
+	}
+	defer c.inflight.Done()
+
+	resource := obj.(*primitives.Resource).DeepCopy()
 	ctx := context.Background()
+
 	l := log.WithFields(log.Fields{
-		"crd_name":      resource.Name,
-		"crd_namespace": resource.Namespace,
-		"resource":      resource.Spec.Name,
-		"team":          resource.Spec.Team,
-		"type":          resource.Spec.Type,
+		logging.ReconcileIDField: logging.NewReconcileID(),
+		"crd_name":               resource.Name,
+		"crd_namespace":          resource.Namespace,
+		"resource":               resource.Spec.Name,
+		"project":                resource.Spec.Project,
+		"team":                   resource.Spec.Team,
+		"account":                resource.Spec.Account,
+		"type":                   resource.Spec.Type,
+	})
+
+	if resource.DeletionTimestamp != nil {
+		c.finalize(l, resource, &resource.ObjectMeta, resourceControllerKind, resource.Spec.DeletionPolicy, resource.Spec.MergeStrategy())
+		return
+	}
+	c.ensureFinalizer(l, resource, &resource.ObjectMeta, resource.Spec.DeletionPolicy, resource.Spec.MergeStrategy())
+
+	paused := isPaused(&resource.ObjectMeta)
+	c.updateStatus(l, resource, &resource.Status, func(s *primitives.Status) bool {
+		return pausedStatus(s, paused)
 	})
+	if paused {
+		l.Debug("resource is paused, skipping")
+		return
+	}
 
-	creds, err := c.mc.GetResourceCredentialValues(ctx, nil, resource.Spec.ManifoldPrimitive())
+	var synced bool
+	defer observeReconcile(kindResource, &resource.ObjectMeta, time.Now(), &synced)
+	defer func() {
+		c.updateStatus(l, resource, &resource.Status, func(s *primitives.Status) bool {
+			return readyStatus(s, synced)
+		})
+	}()
+
+	err := primitives.ValidateDeletionPolicy(resource.Spec.DeletionPolicy)
+	c.updateStatus(l, resource, &resource.Status, func(s *primitives.Status) bool {
+		return specStatus(s, err)
+	})
 	if err != nil {
-		l.WithError(err).Error("could not get resource credentials")
+		l.WithError(err).Error("invalid resource spec")
 		return
 	}
 
-	cmap, err := integrations.FlattenResourceCredentialValues(creds)
+	refresh := refreshRequested(&resource.ObjectMeta, &resource.Status)
+	if refresh != "" {
+		l = l.WithField("refresh_at", refresh)
+		l.Info("refresh requested, bypassing caches")
+	}
+
+	mc, team, err := c.client(resource.Namespace, resource.Spec.Account, refresh != "")
 	if err != nil {
-		l.WithError(err).Error("could not flatten resource credentials")
+		l.WithError(err).Error("could not get manifold client")
 		return
 	}
 
-	// determine if we need to decode values or not
-	encodingKeys := map[string]string{}
-	encodingResourceKeys(resource.Spec, encodingKeys)
+	err = c.checkPolicy(l, resource.Namespace, team, resource.Spec.CredentialRequest())
+	c.updateStatus(l, resource, &resource.Status, func(s *primitives.Status) bool {
+		return policyStatus(s, err)
+	})
+	if err != nil {
+		l.WithError(err).Error("resource not allowed by credential policy")
+		return
+	}
 
-	secretData := decodedByteMap(cmap, encodingKeys)
-	c.createOrUpdateSecret(&resource.ObjectMeta, secretData, resource.Spec.SecretType(), resourceControllerKind)
-}
-func (c *Controller) onResourceDelete(obj interface{}) {
-	resource := obj.(*primitives.Resource)
-	if err := c.kc.Core().Secrets(resource.Namespace).Delete(resource.Name, &metav1.DeleteOptions{}); err != nil {
-		log.WithError(err).Error("issue deleting the resource")
+	cmap, err := resourceCredentials(ctx, mc, resource.Spec)
+	cmap, cached, err := c.withSnapshot(l, refresh != "", snapshotKey(kindResource, resource.Namespace, resource.Spec.Account, resource.Spec.ProjectScope(), resource.Spec.ManifoldPrimitive()), cmap, err)
+	c.updateStatus(l, resource, &resource.Status, func(s *primitives.Status) bool {
+		return cacheStatus(s, cached)
+	})
+	if err != nil {
+		l.WithError(err).Error("could not get resource credentials")
+		return
+	}
+	defer logging.Redact(mapValues(cmap)...)()
+
+	secretData := resourceSecretData(l, resource.Spec, cmap)
+	defer logging.RedactBytes(secretData)()
+
+	synced, err = c.createOrUpdateSecret(l, &resource.ObjectMeta, secretData, resource.Spec.SecretType(), resourceControllerKind, resource.Spec.MergeStrategy(), refresh != "")
+	c.updateStatus(l, resource, &resource.Status, func(s *primitives.Status) bool {
+		return conflictStatus(s, err)
+	})
+	if synced {
+		c.updateStatus(l, resource, &resource.Status, func(s *primitives.Status) bool {
+			return refreshedStatus(s, refresh)
+		})
 	}
 }
 
-func (c *Controller) createOrUpdateSecret(meta *metav1.ObjectMeta, secrets map[string][]byte, secretType v1.SecretType, gkv schema.GroupVersionKind) {
+func (c *Controller) deleteResource(resource *primitives.Resource) {
+	if !c.track() {
+		return
+	}
+	defer c.inflight.Done()
+
+	c.countObjects()
+	forgetObject(kindResource, &resource.ObjectMeta)
+
 	l := log.WithFields(log.Fields{
-		"crd_name":      meta.Name,
-		"crd_namespace": meta.Namespace,
+		logging.ReconcileIDField: logging.NewReconcileID(),
+		"crd_name":               resource.Name,
+		"crd_namespace":          resource.Namespace,
 	})
+	if err := c.applyDeletionPolicy(l, &resource.ObjectMeta, resourceControllerKind, resource.Spec.DeletionPolicy, resource.Spec.MergeStrategy()); err != nil {
+		l.WithError(err).Error("issue deleting the resource")
+	}
+}
 
-	data, err := secretData(secrets, secretType)
+// projectCredentials fetches and flattens the credentials for a Project.
+func projectCredentials(ctx context.Context, mc CredentialSource, spec *primitives.ProjectSpec) (map[string]string, error) {
+	creds, err := mc.GetResourcesCredentialValues(ctx, &spec.Name, spec.ManifoldPrimitive().Resources)
+	if err != nil {
+		return nil, err
+	}
+
+	cmap, err := integrations.FlattenResourcesCredentialValues(creds)
+	if err != nil {
+		return nil, fmt.Errorf("could not flatten credentials: %s", err)
+	}
+
+	return cmap, nil
+}
+
+// resourceCredentials fetches and flattens the credentials for a Resource.
+func resourceCredentials(ctx context.Context, mc CredentialSource, spec *primitives.ResourceSpec) (map[string]string, error) {
+	creds, err := mc.GetResourceCredentialValues(ctx, spec.ProjectScope(), spec.ManifoldPrimitive())
+	if err != nil {
+		return nil, resourceLookupError(spec, err)
+	}
+
+	cmap, err := integrations.FlattenResourceCredentialValues(creds)
+	if err != nil {
+		return nil, fmt.Errorf("could not flatten credentials: %s", err)
+	}
+
+	return cmap, nil
+}
+
+// createOrUpdateSecret writes the secret for the given Project or Resource and
+// reports whether the secret is in sync. Secrets that are already up to date
+// are left untouched, unless the write is forced. Existing secrets the object
+// doesn't control are only written if the adoption policy allows it, or when
+// merging into them, otherwise a *secretConflictError is returned.
+func (c *Controller) createOrUpdateSecret(l *log.Entry, meta *metav1.ObjectMeta, secrets map[string][]byte, secretType v1.SecretType, gkv schema.GroupVersionKind, merge, force bool) (bool, error) {
+	kind := strings.ToLower(gkv.Kind)
+
+	secret, err := newSecret(meta, secrets, secretType, gkv, merge)
 	if err != nil {
 		l.WithError(err).Error("could not create secret")
-		return
+		return false, nil
+	}
+	defer logging.RedactBytes(secret.Data)()
+
+	var changed bool
+	s := c.kc.Core().Secrets(meta.Namespace)
+	existing, err := s.Get(meta.Name, metav1.GetOptions{})
+	if err == nil {
+		if merge {
+			err = checkMerge(l, existing, meta, gkv)
+			if err == nil {
+				err = checkMergeKeys(existing, secret, meta)
+			}
+		} else {
+			err = c.checkAdoption(l, existing, meta, gkv)
+		}
+		if err != nil {
+			l.WithError(err).Error("could not sync secret")
+			secretWritesTotal.WithLabelValues(kind, "conflict").Inc()
+			return false, err
+		}
+
+		if merge {
+			if existing.Type != secret.Type {
+				l.WithField("secret_type", existing.Type).Warn("keeping the type of the merged secret, which can't be changed")
+			}
+			secret.Type = existing.Type
+			secret.Data = MergeSecretData(existing, secret)
+			secret.OwnerReferences = withoutOwner(existing.OwnerReferences, meta)
+			defer logging.RedactBytes(secret.Data)()
+		} else {
+			secret.OwnerReferences = withControllerRef(existing.OwnerReferences, secret.OwnerReferences[0])
+		}
+	}
+
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = s.Create(secret)
+	case err != nil:
+	case !force && secretUpToDate(existing, secret):
+		secretWritesTotal.WithLabelValues(kind, "skipped").Inc()
+		return true, nil
+	default:
+		changed = !dataEqual(existing.Data, secret.Data)
+		setManagedKeys(&existing.ObjectMeta, secret.Annotations[primitives.AnnotationManagedKeys], secret.Annotations[primitives.AnnotationManagedBy])
+		delete(existing.Annotations, primitives.AnnotationOrphanedFrom)
+		existing.OwnerReferences = secret.OwnerReferences
+		existing.Data = secret.Data
+		existing.Type = secret.Type
+		_, err = s.Update(existing)
+	}
+
+	if err != nil {
+		l.WithError(err).Error("could not sync secret")
+		return false, nil
 	}
 
-	secret := v1.Secret{
+	secretWritesTotal.WithLabelValues(kind, "written").Inc()
+
+	// Pods which need the secret can't start before it exists, so workloads
+	// only have to be rolled out when the data of an existing secret changes.
+	if changed {
+		c.rolloutWorkloads(l, secret)
+	}
+
+	return true, nil
+}
+
+// deleteSecret deletes the secret of the given Project or Resource. Secrets
+// which aren't controlled by the object, because they were never adopted, are
+// left alone. Only the managed keys are removed from a merged secret.
+func (c *Controller) deleteSecret(l *log.Entry, meta *metav1.ObjectMeta, gkv schema.GroupVersionKind, merge bool) error {
+	if merge {
+		return c.removeManagedKeys(l, meta, gkv)
+	}
+
+	s := c.kc.Core().Secrets(meta.Namespace)
+	existing, err := s.Get(meta.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	if ref := metav1.GetControllerOf(existing); ref == nil || ref.UID != meta.UID {
+		l.Info("secret isn't controlled by the deleted object, leaving it")
+		return nil
+	}
+
+	return s.Delete(meta.Name, &metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &existing.UID},
+	})
+}
+
+// newSecret returns the secret for the given Project or Resource, owned by
+// it. A secret which is merged isn't owned, it lists the keys of the object in
+// the managed keys annotation instead.
+func newSecret(meta *metav1.ObjectMeta, secrets map[string][]byte, secretType v1.SecretType, gkv schema.GroupVersionKind, merge bool) (*v1.Secret, error) {
+	data, err := secretData(secrets, secretType)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      meta.Name,
 			Namespace: meta.Namespace,
-			OwnerReferences: []metav1.OwnerReference{
-				*metav1.NewControllerRef(meta, gkv),
-			},
 		},
 		Data: data,
 		Type: secretType,
 	}
 
-	s := c.kc.Core().Secrets(meta.Namespace)
-	_, err = s.Update(&secret)
-	if apierrors.IsNotFound(err) {
-		_, err = s.Create(&secret)
+	if merge {
+		setManagedKeys(&secret.ObjectMeta, managedKeysValue(data), managedBy(gkv, meta))
+	} else {
+		secret.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(meta, gkv)}
 	}
 
-	if err != nil {
-		l.WithError(err).Error("could not sync secret")
+	return secret, nil
+}
+
+// secretUpToDate returns whether the existing secret already holds the desired
+// data, managed keys and ownership.
+func secretUpToDate(existing, desired *v1.Secret) bool {
+	if existing.Type != desired.Type || !dataEqual(existing.Data, desired.Data) {
+		return false
+	}
+
+	for _, a := range []string{primitives.AnnotationManagedKeys, primitives.AnnotationManagedBy} {
+		if existing.Annotations[a] != desired.Annotations[a] {
+			return false
+		}
+	}
+
+	if _, ok := existing.Annotations[primitives.AnnotationOrphanedFrom]; ok {
+		return false
 	}
+
+	return reflect.DeepEqual(existing.OwnerReferences, desired.OwnerReferences)
+}
+
+// dataEqual returns whether both secrets hold the same data.
+func dataEqual(a, b map[string][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for k, v := range b {
+		av, ok := a[k]
+		if !ok || !bytes.Equal(av, v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resourceLookupError annotates a failed resource lookup. When a resource isn't
+// scoped to a project, its label can match resources in several Manifold
+// projects, which the Manifold client reports as the resource not being found.
+// We surface that as a possible ambiguity so the user knows to set
+// `spec.project` instead of guessing which project was meant. Any other error
+// is returned as is.
+func resourceLookupError(rs *primitives.ResourceSpec, err error) error {
+	if rs.Project != "" || err != integrations.ErrResourceNotFound {
+		return err
+	}
+
+	return fmt.Errorf("resource '%s' is not scoped to a project and was either not found or matches resources in several projects, set spec.project to disambiguate: %s", rs.Name, err)
+}
+
+// projectSecretData decodes the flattened credentials of a Project into the
+// data of its secret.
+func projectSecretData(l *log.Entry, spec *primitives.ProjectSpec, cmap map[string]string) map[string][]byte {
+	// determine if we need to decode values or not
+	encodingKeys := map[string]string{}
+	for _, resource := range spec.Resources {
+		encodingResourceKeys(resource, encodingKeys)
+	}
+
+	return decodedByteMap(l, cmap, encodingKeys)
+}
+
+// resourceSecretData decodes the flattened credentials of a Resource into the
+// data of its secret.
+func resourceSecretData(l *log.Entry, spec *primitives.ResourceSpec, cmap map[string]string) map[string][]byte {
+	encodingKeys := map[string]string{}
+	encodingResourceKeys(spec, encodingKeys)
+
+	return decodedByteMap(l, cmap, encodingKeys)
 }
 
 func decodeValue(encoding, value string) ([]byte, error) {
 	switch encoding {
-	case "base64":
+	case primitives.EncodingBase64:
 		return base64.StdEncoding.DecodeString(value)
 	default:
 		return nil, fmt.Errorf("Encoding '%s' not supported", encoding)
@@ -225,16 +879,21 @@ func encodingResourceKeys(r *primitives.ResourceSpec, keys map[string]string) {
 	}
 }
 
-func decodedByteMap(cmap, encodingKeys map[string]string) map[string][]byte {
+// decodedByteMap decodes the values which have an encoding configured. Values
+// that can't be decoded are kept as is. Only the key is ever logged, never the
+// value or the decoded bytes.
+func decodedByteMap(l *log.Entry, cmap, encodingKeys map[string]string) map[string][]byte {
 	secretData := make(map[string][]byte)
 	for k, v := range cmap {
 		var bts = []byte(v)
 
 		if e, ok := encodingKeys[k]; ok {
-			var err error
-			bts, err = decodeValue(e, v)
+			decoded, err := decodeValue(e, v)
 			if err != nil {
-				log.WithField("key", k).WithError(err).Error("could not decode value")
+				decodeFailuresTotal.Inc()
+				l.WithField("key", k).WithField("encoding", e).WithError(err).Error("could not decode value")
+			} else {
+				bts = decoded
 			}
 		}
 
@@ -243,3 +902,12 @@ func decodedByteMap(cmap, encodingKeys map[string]string) map[string][]byte {
 
 	return secretData
 }
+
+func mapValues(m map[string]string) []string {
+	values := make([]string, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+
+	return values
+}
@@ -0,0 +1,436 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/manifoldco/go-manifold/integrations"
+	crdfake "github.com/manifoldco/kubernetes-credentials/client/clientset/versioned/fake"
+	"github.com/manifoldco/kubernetes-credentials/filesource"
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+var testCredentials = map[string]filesource.Team{
+	"manifold": {
+		"production": {
+			"db":    {"USERNAME": "prod-user", "PASSWORD": "prod-pass", "CERT": "Y2VydA==", "BROKEN": "not base64!"},
+			"cache": {"URL": "redis://prod"},
+		},
+	},
+	"other": {
+		"staging": {
+			"db": {"USERNAME": "other-user", "PASSWORD": "other-pass"},
+		},
+	},
+}
+
+// newTestController returns a controller backed by fake clientsets with the
+// given objects and the test credentials. Its caches only hold the given
+// objects and are considered synced.
+func newTestController(objects, crdObjects []runtime.Object) (*Controller, *fake.Clientset, *crdfake.Clientset) {
+	kc := fake.NewSimpleClientset(objects...)
+	crds := crdfake.NewSimpleClientset(crdObjects...)
+	cf := func(token, team string) (CredentialSource, error) {
+		if token != "other-token" {
+			return nil, fmt.Errorf("invalid token '%s'", token)
+		}
+
+		return filesource.New(team, testCredentials), nil
+	}
+
+	c := New(kc, crds, filesource.New("manifold", testCredentials), cf, Options{Team: "manifold"})
+	c.policiesSynced = func() bool { return true }
+
+	for _, obj := range objects {
+		switch obj.(type) {
+		case *v1.Secret:
+			c.kinformers.Core().V1().Secrets().Informer().GetIndexer().Add(obj)
+		case *v1.Namespace:
+			c.kinformers.Core().V1().Namespaces().Informer().GetIndexer().Add(obj)
+		case *appsv1.Deployment:
+			c.kinformers.Apps().V1().Deployments().Informer().GetIndexer().Add(obj)
+		}
+	}
+	for _, obj := range crdObjects {
+		switch obj.(type) {
+		case *primitives.ManifoldAccount:
+			c.informers.Manifold().V1().ManifoldAccounts().Informer().GetIndexer().Add(obj)
+		case *primitives.CredentialPolicy:
+			c.informers.Manifold().V1().CredentialPolicies().Informer().GetIndexer().Add(obj)
+		case *primitives.Project:
+			c.informers.Manifold().V1().Projects().Informer().GetIndexer().Add(obj)
+		case *primitives.Resource:
+			c.informers.Manifold().V1().Resources().Informer().GetIndexer().Add(obj)
+		}
+	}
+
+	return c, kc, crds
+}
+
+func testProject(spec *primitives.ProjectSpec) *primitives.Project {
+	return &primitives.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "default", UID: "project-uid"},
+		Spec:       spec,
+	}
+}
+
+func testResource(spec *primitives.ResourceSpec) *primitives.Resource {
+	return &primitives.Resource{
+		ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "default", UID: "resource-uid"},
+		Spec:       spec,
+	}
+}
+
+func existingSecret(data map[string]string) *v1.Secret {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "default"},
+		Data:       map[string][]byte{},
+		Type:       v1.SecretTypeOpaque,
+	}
+	for k, v := range data {
+		secret.Data[k] = []byte(v)
+	}
+
+	return secret
+}
+
+// controlledSecret returns an existing secret which is controlled by the
+// object with the given UID.
+func controlledSecret(uid string, data map[string]string) *v1.Secret {
+	secret := existingSecret(data)
+	secret.OwnerReferences = []metav1.OwnerReference{
+		*metav1.NewControllerRef(&metav1.ObjectMeta{Name: "creds", UID: types.UID(uid)}, projectControllerKind),
+	}
+
+	return secret
+}
+
+func accountObjects() (*primitives.ManifoldAccount, *v1.Secret) {
+	acct := &primitives.ManifoldAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+		Spec:       &primitives.ManifoldAccountSpec{SecretName: "other-token"},
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-token", Namespace: "default"},
+		Data: map[string][]byte{
+			primitives.DefaultAccountTokenKey: []byte("other-token"),
+			primitives.DefaultAccountTeamKey:  []byte("other"),
+		},
+	}
+
+	return acct, secret
+}
+
+// expectSecret verifies that the secret of the test objects holds exactly the
+// expected data and is owned by the object with the given UID.
+func expectSecret(t *testing.T, kc *fake.Clientset, uid string, expected map[string]string) {
+	t.Helper()
+
+	secret, err := kc.CoreV1().Secrets("default").Get("creds", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected no error getting the secret, got %q", err)
+	}
+
+	if len(secret.Data) != len(expected) {
+		t.Errorf("expected %d keys, got %d: %v", len(expected), len(secret.Data), secret.Data)
+	}
+	for k, v := range expected {
+		if got := string(secret.Data[k]); got != v {
+			t.Errorf("expected %s to eq %q, got %q", k, v, got)
+		}
+	}
+
+	if len(secret.OwnerReferences) != 1 || string(secret.OwnerReferences[0].UID) != uid {
+		t.Errorf("expected the secret to be owned by %q, got %v", uid, secret.OwnerReferences)
+	}
+}
+
+func expectReady(t *testing.T, status *primitives.Status, expected v1.ConditionStatus) {
+	t.Helper()
+
+	cond := status.Condition(primitives.ConditionReady)
+	if cond == nil {
+		t.Fatalf("expected a %s condition", primitives.ConditionReady)
+	}
+	if cond.Status != expected {
+		t.Errorf("expected %s to eq %q, got %q", primitives.ConditionReady, expected, cond.Status)
+	}
+}
+
+func TestCreateOrUpdateProject(t *testing.T) {
+	acct, acctSecret := accountObjects()
+
+	tcs := []struct {
+		scenario string
+		objects  []runtime.Object
+		accounts []runtime.Object
+		spec     *primitives.ProjectSpec
+		expected map[string]string
+		ready    v1.ConditionStatus
+	}{
+		{
+			scenario: "creates the secret",
+			spec:     &primitives.ProjectSpec{Name: "production"},
+			expected: map[string]string{
+				"USERNAME": "prod-user",
+				"PASSWORD": "prod-pass",
+				"CERT":     "Y2VydA==",
+				"BROKEN":   "not base64!",
+				"URL":      "redis://prod",
+			},
+			ready: v1.ConditionTrue,
+		},
+		{
+			scenario: "updates an outdated secret",
+			objects:  []runtime.Object{controlledSecret("project-uid", map[string]string{"USERNAME": "old-user", "OLD": "value"})},
+			spec: &primitives.ProjectSpec{
+				Name: "production",
+				Resources: []*primitives.ResourceSpec{
+					{Name: "db", Credentials: []*primitives.CredentialSpec{{Key: "USERNAME"}}},
+				},
+			},
+			expected: map[string]string{"USERNAME": "prod-user"},
+			ready:    v1.ConditionTrue,
+		},
+		{
+			scenario: "aliases keys",
+			spec: &primitives.ProjectSpec{
+				Name: "production",
+				Resources: []*primitives.ResourceSpec{
+					{Name: "db", Credentials: []*primitives.CredentialSpec{{Key: "PASSWORD", Name: "DB_PASSWORD"}}},
+					{Name: "cache", Credentials: []*primitives.CredentialSpec{{Key: "URL", Name: "REDIS_URL"}}},
+				},
+			},
+			expected: map[string]string{"DB_PASSWORD": "prod-pass", "REDIS_URL": "redis://prod"},
+			ready:    v1.ConditionTrue,
+		},
+		{
+			scenario: "uses defaults for missing keys",
+			spec: &primitives.ProjectSpec{
+				Name: "production",
+				Resources: []*primitives.ResourceSpec{
+					{Name: "db", Credentials: []*primitives.CredentialSpec{
+						{Key: "USERNAME", Default: "default-user"},
+						{Key: "PORT", Default: "5432"},
+					}},
+				},
+			},
+			expected: map[string]string{"USERNAME": "prod-user", "PORT": "5432"},
+			ready:    v1.ConditionTrue,
+		},
+		{
+			scenario: "decodes values and keeps the ones that can't be decoded",
+			spec: &primitives.ProjectSpec{
+				Name: "production",
+				Resources: []*primitives.ResourceSpec{
+					{Name: "db", Credentials: []*primitives.CredentialSpec{
+						{Key: "CERT", Encoding: primitives.EncodingBase64},
+						{Key: "BROKEN", Encoding: primitives.EncodingBase64},
+					}},
+				},
+			},
+			expected: map[string]string{"CERT": "cert", "BROKEN": "not base64!"},
+			ready:    v1.ConditionTrue,
+		},
+		{
+			scenario: "loads credentials through an account",
+			objects:  []runtime.Object{acctSecret},
+			accounts: []runtime.Object{acct},
+			spec:     &primitives.ProjectSpec{Name: "staging", Account: "other"},
+			expected: map[string]string{"USERNAME": "other-user", "PASSWORD": "other-pass"},
+			ready:    v1.ConditionTrue,
+		},
+		{
+			scenario: "fails for a missing project",
+			spec:     &primitives.ProjectSpec{Name: "unknown"},
+			ready:    v1.ConditionFalse,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			project := testProject(tc.spec)
+			c, kc, crds := newTestController(tc.objects, append(tc.accounts, project))
+
+			c.createOrUpdateProject(project)
+
+			if tc.expected != nil {
+				expectSecret(t, kc, "project-uid", tc.expected)
+			} else if _, err := kc.CoreV1().Secrets("default").Get("creds", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+				t.Errorf("expected no secret, got %v", err)
+			}
+
+			updated, err := crds.ManifoldV1().Projects("default").Get("creds", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("expected no error getting the project, got %q", err)
+			}
+			expectReady(t, &updated.Status, tc.ready)
+		})
+	}
+}
+
+func TestCreateOrUpdateResource(t *testing.T) {
+	tcs := []struct {
+		scenario string
+		objects  []runtime.Object
+		spec     *primitives.ResourceSpec
+		expected map[string]string
+		ready    v1.ConditionStatus
+	}{
+		{
+			scenario: "creates the secret",
+			spec:     &primitives.ResourceSpec{Name: "cache", Project: "production"},
+			expected: map[string]string{"URL": "redis://prod"},
+			ready:    v1.ConditionTrue,
+		},
+		{
+			scenario: "updates an outdated secret",
+			objects:  []runtime.Object{controlledSecret("resource-uid", map[string]string{"URL": "redis://old"})},
+			spec:     &primitives.ResourceSpec{Name: "cache", Project: "production"},
+			expected: map[string]string{"URL": "redis://prod"},
+			ready:    v1.ConditionTrue,
+		},
+		{
+			scenario: "aliases, defaults and decodes keys",
+			spec: &primitives.ResourceSpec{
+				Name:    "db",
+				Project: "production",
+				Credentials: []*primitives.CredentialSpec{
+					{Key: "PASSWORD", Name: "DB_PASSWORD"},
+					{Key: "PORT", Default: "5432"},
+					{Key: "CERT", Name: "DB_CERT", Encoding: primitives.EncodingBase64},
+					{Key: "BROKEN", Encoding: primitives.EncodingBase64},
+				},
+			},
+			expected: map[string]string{
+				"DB_PASSWORD": "prod-pass",
+				"PORT":        "5432",
+				"DB_CERT":     "cert",
+				"BROKEN":      "not base64!",
+			},
+			ready: v1.ConditionTrue,
+		},
+		{
+			scenario: "fails for a missing resource",
+			spec:     &primitives.ResourceSpec{Name: "unknown", Project: "production"},
+			ready:    v1.ConditionFalse,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			resource := testResource(tc.spec)
+			c, kc, crds := newTestController(tc.objects, []runtime.Object{resource})
+
+			c.createOrUpdateResource(resource)
+
+			if tc.expected != nil {
+				expectSecret(t, kc, "resource-uid", tc.expected)
+			} else if _, err := kc.CoreV1().Secrets("default").Get("creds", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+				t.Errorf("expected no secret, got %v", err)
+			}
+
+			updated, err := crds.ManifoldV1().Resources("default").Get("creds", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("expected no error getting the resource, got %q", err)
+			}
+			expectReady(t, &updated.Status, tc.ready)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tcs := []struct {
+		scenario string
+		secret   *v1.Secret
+		delete   func(c *Controller)
+		deleted  bool
+	}{
+		{
+			scenario: "deleting a project",
+			secret:   controlledSecret("project-uid", map[string]string{"USERNAME": "prod-user"}),
+			delete: func(c *Controller) {
+				c.onProjectDelete(testProject(&primitives.ProjectSpec{Name: "production"}))
+			},
+			deleted: true,
+		},
+		{
+			scenario: "deleting a resource",
+			secret:   controlledSecret("resource-uid", map[string]string{"USERNAME": "prod-user"}),
+			delete: func(c *Controller) {
+				c.onResourceDelete(testResource(&primitives.ResourceSpec{Name: "db"}))
+			},
+			deleted: true,
+		},
+		{
+			scenario: "keeps a secret it doesn't control",
+			secret:   existingSecret(map[string]string{"USERNAME": "prod-user"}),
+			delete: func(c *Controller) {
+				c.onProjectDelete(testProject(&primitives.ProjectSpec{Name: "production"}))
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			c, kc, _ := newTestController([]runtime.Object{tc.secret}, nil)
+
+			tc.delete(c)
+			c.processNextItem()
+
+			_, err := kc.CoreV1().Secrets("default").Get("creds", metav1.GetOptions{})
+			if tc.deleted && !apierrors.IsNotFound(err) {
+				t.Errorf("expected the secret to be deleted, got %v", err)
+			}
+			if !tc.deleted && err != nil {
+				t.Errorf("expected the secret to be kept, got %v", err)
+			}
+		})
+	}
+}
+
+func TestResourceLookupError(t *testing.T) {
+	other := errors.New("unauthorized")
+
+	tcs := []struct {
+		scenario string
+		spec     *primitives.ResourceSpec
+		err      error
+		wrapped  bool
+	}{
+		{
+			scenario: "hints at an ambiguous label when an unscoped resource isn't found",
+			spec:     &primitives.ResourceSpec{Name: "db"},
+			err:      integrations.ErrResourceNotFound,
+			wrapped:  true,
+		},
+		{
+			scenario: "keeps other errors of an unscoped resource",
+			spec:     &primitives.ResourceSpec{Name: "db"},
+			err:      other,
+		},
+		{
+			scenario: "keeps the errors of a scoped resource",
+			spec:     &primitives.ResourceSpec{Name: "db", Project: "production"},
+			err:      integrations.ErrResourceNotFound,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			err := resourceLookupError(tc.spec, tc.err)
+			if wrapped := err != tc.err; wrapped != tc.wrapped {
+				t.Errorf("expected the error to be wrapped to be %t, got %q", tc.wrapped, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package controller
+
+import (
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+// deletionPolicy returns the deletion policy for an object with the given
+// policy in its spec, falling back to the default of the controller.
+func (c *Controller) deletionPolicy(policy string) string {
+	switch {
+	case policy != "":
+		return policy
+	case c.defaultDeletionPolicy != "":
+		return c.defaultDeletionPolicy
+	default:
+		return primitives.DeletionPolicyDelete
+	}
+}
+
+// orphanedFrom returns the value of the orphaned-from annotation for the
+// object with the given kind and metadata.
+func orphanedFrom(gkv schema.GroupVersionKind, meta *metav1.ObjectMeta) string {
+	return gkv.Kind + "/" + meta.Name
+}
+
+// setFinalizer adds or removes the secret finalizer and reports whether the
+// finalizers changed.
+func setFinalizer(meta *metav1.ObjectMeta, needed bool) bool {
+	for i, f := range meta.Finalizers {
+		if f != primitives.FinalizerSecret {
+			continue
+		}
+		if needed {
+			return false
+		}
+
+		meta.Finalizers = append(meta.Finalizers[:i:i], meta.Finalizers[i+1:]...)
+		return true
+	}
+
+	if !needed {
+		return false
+	}
+
+	meta.Finalizers = append(meta.Finalizers, primitives.FinalizerSecret)
+	return true
+}
+
+// ensureFinalizer makes sure the object only has the secret finalizer when its
+// secret is kept on deletion, or when it's merged into. Without the finalizer,
+// the garbage collector could delete the secret along with the object before
+// the controller releases it. A merged secret has no owner reference, so its
+// managed keys would be left behind if the controller missed the deletion.
+func (c *Controller) ensureFinalizer(l *log.Entry, obj runtime.Object, meta *metav1.ObjectMeta, policy string, merge bool) {
+	if !setFinalizer(meta, merge || c.deletionPolicy(policy) != primitives.DeletionPolicyDelete) {
+		return
+	}
+
+	if err := c.update(obj); err != nil {
+		l.WithError(err).Error("could not update the finalizers")
+	}
+}
+
+// finalize applies the deletion policy to the secret of an object which is
+// being deleted and then removes the secret finalizer, which lets the deletion
+// finish. When the policy can't be applied, the finalizer is kept and it's
+// retried on the next resync.
+func (c *Controller) finalize(l *log.Entry, obj runtime.Object, meta *metav1.ObjectMeta, gkv schema.GroupVersionKind, policy string, merge bool) {
+	if err := c.applyDeletionPolicy(l, meta, gkv, policy, merge); err != nil {
+		l.WithError(err).Error("could not apply the deletion policy")
+		return
+	}
+
+	if !setFinalizer(meta, false) {
+		return
+	}
+
+	if err := c.update(obj); err != nil {
+		l.WithError(err).Error("could not update the finalizers")
+	}
+}
+
+// applyDeletionPolicy deletes or releases the secret of a deleted Project or
+// Resource, depending on its deletion policy. An unsupported policy, which the
+// object was never reconciled with, releases the secret like Orphan, as it may
+// have been meant to be kept.
+func (c *Controller) applyDeletionPolicy(l *log.Entry, meta *metav1.ObjectMeta, gkv schema.GroupVersionKind, policy string, merge bool) error {
+	policy = c.deletionPolicy(policy)
+	l = l.WithField("deletion_policy", policy)
+
+	if err := primitives.ValidateDeletionPolicy(policy); err != nil {
+		l.WithError(err).Warn("keeping the secret of the deleted object")
+		policy = primitives.DeletionPolicyOrphan
+	}
+
+	if policy == primitives.DeletionPolicyDelete {
+		return c.deleteSecret(l, meta, gkv, merge)
+	}
+
+	return c.releaseSecret(l, meta, gkv, policy, merge)
+}
+
+// releaseSecret keeps the secret of a deleted Project or Resource but removes
+// the object's owner reference, so it isn't garbage collected. With the
+// Retain policy, the secret is also marked as orphaned. Secrets which don't
+// belong to the object are left alone.
+func (c *Controller) releaseSecret(l *log.Entry, meta *metav1.ObjectMeta, gkv schema.GroupVersionKind, policy string, merge bool) error {
+	s := c.kc.Core().Secrets(meta.Namespace)
+	existing, err := s.Get(meta.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	ref := metav1.GetControllerOf(existing)
+	owned := ref != nil && ref.UID == meta.UID
+	if merge {
+		owned = len(managedKeys(existing)) > 0 && checkMerge(l, existing, meta, gkv) == nil
+	}
+	if !owned {
+		l.Debug("secret doesn't belong to the deleted object, leaving it")
+		return nil
+	}
+
+	existing.OwnerReferences = withoutOwner(existing.OwnerReferences, meta)
+	if policy == primitives.DeletionPolicyRetain {
+		if existing.Annotations == nil {
+			existing.Annotations = map[string]string{}
+		}
+		existing.Annotations[primitives.AnnotationOrphanedFrom] = orphanedFrom(gkv, meta)
+	}
+
+	if _, err := s.Update(existing); err != nil {
+		return err
+	}
+
+	l.Info("kept the secret of the deleted object")
+	return nil
+}
@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+func TestDelete_deletionPolicy(t *testing.T) {
+	tcs := []struct {
+		scenario string
+		policy   string
+		fallback string
+		deleted  bool
+		orphaned string
+	}{
+		{
+			scenario: "deletes the secret by default",
+			deleted:  true,
+		},
+		{
+			scenario: "deletes the secret with the Delete policy",
+			policy:   primitives.DeletionPolicyDelete,
+			fallback: primitives.DeletionPolicyRetain,
+			deleted:  true,
+		},
+		{
+			scenario: "retains the secret with the Retain policy",
+			policy:   primitives.DeletionPolicyRetain,
+			orphaned: "Project/creds",
+		},
+		{
+			scenario: "orphans the secret with the Orphan policy",
+			policy:   primitives.DeletionPolicyOrphan,
+		},
+		{
+			scenario: "uses the default policy of the controller",
+			fallback: primitives.DeletionPolicyRetain,
+			orphaned: "Project/creds",
+		},
+		{
+			scenario: "keeps the secret with an unsupported policy",
+			policy:   "Keep",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			c, kc, _ := newTestController([]runtime.Object{
+				controlledSecret("project-uid", map[string]string{"USERNAME": "prod-user"}),
+			}, nil)
+			c.defaultDeletionPolicy = tc.fallback
+
+			c.deleteProject(testProject(&primitives.ProjectSpec{Name: "production", DeletionPolicy: tc.policy}))
+
+			secret, err := kc.CoreV1().Secrets("default").Get("creds", metav1.GetOptions{})
+			if tc.deleted {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("expected the secret to be deleted, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected the secret to be kept, got %q", err)
+			}
+			if got := string(secret.Data["USERNAME"]); got != "prod-user" {
+				t.Errorf("expected USERNAME to eq %q, got %q", "prod-user", got)
+			}
+			if len(secret.OwnerReferences) != 0 {
+				t.Errorf("expected a kept secret to have no owners, got %v", secret.OwnerReferences)
+			}
+			if got := secret.Annotations[primitives.AnnotationOrphanedFrom]; got != tc.orphaned {
+				t.Errorf("expected the orphaned-from annotation to eq %q, got %q", tc.orphaned, got)
+			}
+		})
+	}
+}
+
+func TestCreateOrUpdateProject_invalidDeletionPolicy(t *testing.T) {
+	spec := &primitives.ProjectSpec{
+		Name:           "production",
+		DeletionPolicy: "Keep",
+		Resources: []*primitives.ResourceSpec{
+			{Name: "db", Credentials: []*primitives.CredentialSpec{{Key: "USERNAME"}}},
+		},
+	}
+	project := testProject(spec)
+	c, kc, crds := newTestController(nil, []runtime.Object{project})
+
+	c.createOrUpdateProject(project)
+
+	if _, err := kc.CoreV1().Secrets("default").Get("creds", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected no secret, got %v", err)
+	}
+
+	updated, err := crds.ManifoldV1().Projects("default").Get("creds", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected no error getting the project, got %q", err)
+	}
+	cond := updated.Status.Condition(primitives.ConditionInvalidSpec)
+	if cond == nil || cond.Status != v1.ConditionTrue || cond.Reason != "InvalidDeletionPolicy" {
+		t.Fatalf("expected a %s condition with reason %q, got %v", primitives.ConditionInvalidSpec, "InvalidDeletionPolicy", cond)
+	}
+	expectReady(t, &updated.Status, v1.ConditionFalse)
+
+	updated.Spec.DeletionPolicy = primitives.DeletionPolicyOrphan
+	c.createOrUpdateProject(updated)
+
+	updated, err = crds.ManifoldV1().Projects("default").Get("creds", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected no error getting the project, got %q", err)
+	}
+	if cond := updated.Status.Condition(primitives.ConditionInvalidSpec); cond != nil {
+		t.Errorf("expected the %s condition to be removed, got %v", primitives.ConditionInvalidSpec, cond)
+	}
+	expectReady(t, &updated.Status, v1.ConditionTrue)
+	expectSecret(t, kc, "project-uid", map[string]string{"USERNAME": "prod-user"})
+}
+
+func TestCreateOrUpdateProject_finalizer(t *testing.T) {
+	spec := &primitives.ProjectSpec{
+		Name:           "production",
+		DeletionPolicy: primitives.DeletionPolicyRetain,
+		Resources: []*primitives.ResourceSpec{
+			{Name: "db", Credentials: []*primitives.CredentialSpec{{Key: "USERNAME"}}},
+		},
+	}
+
+	t.Run("adds the finalizer when the secret is kept", func(t *testing.T) {
+		project := testProject(spec)
+		c, _, crds := newTestController(nil, []runtime.Object{project})
+
+		c.createOrUpdateProject(project)
+
+		updated, err := crds.ManifoldV1().Projects("default").Get("creds", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("expected no error getting the project, got %q", err)
+		}
+		if len(updated.Finalizers) != 1 || updated.Finalizers[0] != primitives.FinalizerSecret {
+			t.Errorf("expected the finalizers to eq [%s], got %v", primitives.FinalizerSecret, updated.Finalizers)
+		}
+	})
+
+	t.Run("releases the secret and removes the finalizer on deletion", func(t *testing.T) {
+		project := testProject(spec)
+		project.DeletionTimestamp = &metav1.Time{}
+		project.Finalizers = []string{primitives.FinalizerSecret}
+		c, kc, crds := newTestController([]runtime.Object{
+			controlledSecret("project-uid", map[string]string{"USERNAME": "prod-user"}),
+		}, []runtime.Object{project})
+
+		c.createOrUpdateProject(project)
+
+		secret, err := kc.CoreV1().Secrets("default").Get("creds", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("expected the secret to be kept, got %q", err)
+		}
+		if len(secret.OwnerReferences) != 0 {
+			t.Errorf("expected a kept secret to have no owners, got %v", secret.OwnerReferences)
+		}
+		if got := secret.Annotations[primitives.AnnotationOrphanedFrom]; got != "Project/creds" {
+			t.Errorf("expected the orphaned-from annotation to eq %q, got %q", "Project/creds", got)
+		}
+
+		updated, err := crds.ManifoldV1().Projects("default").Get("creds", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("expected no error getting the project, got %q", err)
+		}
+		if len(updated.Finalizers) != 0 {
+			t.Errorf("expected the finalizer to be removed, got %v", updated.Finalizers)
+		}
+	})
+}
+
+func TestAdoption_retained(t *testing.T) {
+	retained := existingSecret(map[string]string{"USERNAME": "old-user"})
+	retained.Annotations = map[string]string{primitives.AnnotationOrphanedFrom: "Project/creds"}
+
+	project := testProject(&primitives.ProjectSpec{
+		Name: "production",
+		Resources: []*primitives.ResourceSpec{
+			{Name: "db", Credentials: []*primitives.CredentialSpec{{Key: "USERNAME"}}},
+		},
+	})
+	c, kc, _ := newTestController([]runtime.Object{retained}, []runtime.Object{project})
+	c.adoption = AdoptNever
+
+	c.createOrUpdateProject(project)
+
+	expectSecret(t, kc, "project-uid", map[string]string{"USERNAME": "prod-user"})
+
+	secret, err := kc.CoreV1().Secrets("default").Get("creds", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected no error getting the secret, got %q", err)
+	}
+	if _, ok := secret.Annotations[primitives.AnnotationOrphanedFrom]; ok {
+		t.Errorf("expected the orphaned-from annotation to be removed")
+	}
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/manifoldco/kubernetes-credentials/throttle"
+)
+
+// errNotStarted is used when the health of the controller is checked before
+// it has been started.
+var errNotStarted = errors.New("controller has not been started")
+
+// Healthy returns an error when one of the informers has stopped running.
+func (c *Controller) Healthy() error {
+	c.mu.RLock()
+	expected := len(c.synced)
+	c.mu.RUnlock()
+
+	if expected == 0 {
+		return errNotStarted
+	}
+
+	if running := int(atomic.LoadInt32(&c.running)); running != expected {
+		return fmt.Errorf("%d of %d informers are running", running, expected)
+	}
+
+	return nil
+}
+
+// Ready returns an error when the informer caches haven't synced yet, or when
+// calls to the Manifold API have been failing, or the circuit breaker has
+// been open, for longer than the given duration.
+func (c *Controller) Ready(maxFailing time.Duration) error {
+	if err := c.Healthy(); err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	synced := c.synced
+	c.mu.RUnlock()
+
+	for _, hasSynced := range synced {
+		if !hasSynced() {
+			return errors.New("informer caches have not synced yet")
+		}
+	}
+
+	if c.throttle != nil {
+		state, since := c.throttle.Breaker.State()
+		if open := time.Since(since); state != throttle.StateClosed && open > maxFailing {
+			return fmt.Errorf("Manifold API circuit breaker has been %s for %s", state, open.Round(time.Second))
+		}
+	}
+
+	lastSuccess := atomic.LoadInt64(&c.lastAPISuccess)
+	lastFailure := atomic.LoadInt64(&c.lastAPIFailure)
+	if lastFailure <= lastSuccess {
+		return nil
+	}
+
+	since := c.started
+	if lastSuccess > 0 {
+		since = time.Unix(0, lastSuccess)
+	}
+
+	if failing := time.Since(since); failing > maxFailing {
+		return fmt.Errorf("Manifold API calls have been failing for %s", failing.Round(time.Second))
+	}
+
+	return nil
+}
+
+// recordAPICall keeps track of the last successful and failed Manifold API
+// calls for the readiness check. Calls rejected by the circuit breaker never
+// reached the API and aren't recorded.
+func (c *Controller) recordAPICall(err error) {
+	if throttle.IsOpen(err) {
+		return
+	}
+
+	now := time.Now().UnixNano()
+	if err != nil {
+		atomic.StoreInt64(&c.lastAPIFailure, now)
+		return
+	}
+
+	atomic.StoreInt64(&c.lastAPISuccess, now)
+}
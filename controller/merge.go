@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/manifoldco/kubernetes-credentials/crd"
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+// managedKeys returns the keys the controller manages in the given secret.
+// Secrets written with the merge strategy are shared with other writers, so
+// they don't get an owner reference, which would have the garbage collector
+// delete the other keys along with the Project or Resource. The managed keys
+// annotation tracks which keys are ours instead.
+func managedKeys(secret *v1.Secret) []string {
+	value := secret.Annotations[primitives.AnnotationManagedKeys]
+	if value == "" {
+		return nil
+	}
+
+	return strings.Split(value, ",")
+}
+
+// managedKeysValue returns the managed keys annotation for the given data.
+func managedKeysValue(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return strings.Join(keys, ",")
+}
+
+// managedBy returns the value of the managed-by annotation for the object
+// with the given kind and metadata.
+func managedBy(gkv schema.GroupVersionKind, meta *metav1.ObjectMeta) string {
+	return gkv.Kind + "/" + meta.Name
+}
+
+// setManagedKeys sets the managed keys annotation and the object they're
+// managed by, or removes both when the value is empty.
+func setManagedKeys(meta *metav1.ObjectMeta, value, owner string) {
+	if value == "" {
+		delete(meta.Annotations, primitives.AnnotationManagedKeys)
+		delete(meta.Annotations, primitives.AnnotationManagedBy)
+		return
+	}
+
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[primitives.AnnotationManagedKeys] = value
+	meta.Annotations[primitives.AnnotationManagedBy] = owner
+}
+
+// MergeSecretData returns the data of the live secret with the keys the
+// controller manages replaced by the data of the desired secret. Managed keys
+// that are no longer desired are removed, all other keys are kept as is.
+func MergeSecretData(live, desired *v1.Secret) map[string][]byte {
+	data := make(map[string][]byte, len(live.Data)+len(desired.Data))
+	for k, v := range live.Data {
+		data[k] = v
+	}
+
+	for _, k := range managedKeys(live) {
+		delete(data, k)
+	}
+
+	for k, v := range desired.Data {
+		data[k] = v
+	}
+
+	return data
+}
+
+// checkMergeKeys returns an error if the desired secret would overwrite keys
+// of the existing secret which weren't written by the controller. They'd be
+// listed as managed afterwards and removed along with the Project or
+// Resource. All keys of a secret the object controls were written by it.
+func checkMergeKeys(existing, desired *v1.Secret, meta *metav1.ObjectMeta) error {
+	if ref := metav1.GetControllerOf(existing); ref != nil && ref.UID == meta.UID {
+		return nil
+	}
+
+	keys := takenOverKeys(existing, desired)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return &secretConflictError{
+		reason:  "UnmanagedKeys",
+		message: fmt.Sprintf("secret '%s' already has keys %s which aren't managed by the controller", existing.Name, strings.Join(keys, ",")),
+	}
+}
+
+// takenOverKeys returns the desired keys which the live secret already holds
+// but which weren't written by the controller.
+func takenOverKeys(live, desired *v1.Secret) []string {
+	managed := map[string]bool{}
+	for _, k := range managedKeys(live) {
+		managed[k] = true
+	}
+
+	var keys []string
+	for k := range desired.Data {
+		if _, ok := live.Data[k]; ok && !managed[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// checkMerge returns an error if the controller can't merge its keys into the
+// existing secret for the object with the given kind and metadata. Merging
+// leaves the other keys and owners alone, so only secrets which are
+// controlled by another Project or Resource, and would be replaced by it, or
+// which another Project or Resource already merges its keys into, are
+// refused. Secrets merged into before the managed-by annotation was added
+// are taken as ours.
+func checkMerge(l *log.Entry, existing *v1.Secret, meta *metav1.ObjectMeta, gkv schema.GroupVersionKind) error {
+	if owner := existing.Annotations[primitives.AnnotationManagedBy]; owner != "" && owner != managedBy(gkv, meta) && len(managedKeys(existing)) > 0 {
+		return &secretConflictError{
+			reason:  "ManagedByOtherObject",
+			message: fmt.Sprintf("the keys of secret '%s' are managed by %s", existing.Name, owner),
+		}
+	}
+
+	ref := metav1.GetControllerOf(existing)
+	if ref == nil || ref.UID == meta.UID || ref.APIVersion != crd.SchemeGroupVersion.String() {
+		return nil
+	}
+
+	return &secretConflictError{
+		reason:  "OwnedByOtherController",
+		message: fmt.Sprintf("secret '%s' is controlled by %s '%s'", existing.Name, ref.Kind, ref.Name),
+	}
+}
+
+// withoutOwner returns the owner references without the ones of the object
+// with the given metadata.
+func withoutOwner(refs []metav1.OwnerReference, meta *metav1.ObjectMeta) []metav1.OwnerReference {
+	var owners []metav1.OwnerReference
+	for _, r := range refs {
+		if r.UID != meta.UID {
+			owners = append(owners, r)
+		}
+	}
+
+	return owners
+}
+
+// removeManagedKeys removes the keys the controller manages from the merged
+// secret of the given Project or Resource. The secret is deleted once no other
+// keys are left.
+func (c *Controller) removeManagedKeys(l *log.Entry, meta *metav1.ObjectMeta, gkv schema.GroupVersionKind) error {
+	s := c.kc.Core().Secrets(meta.Namespace)
+	existing, err := s.Get(meta.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	keys := managedKeys(existing)
+	if len(keys) == 0 || checkMerge(l, existing, meta, gkv) != nil {
+		l.Info("secret has no keys managed by the deleted object, leaving it")
+		return nil
+	}
+
+	for _, k := range keys {
+		delete(existing.Data, k)
+	}
+
+	if len(existing.Data) == 0 {
+		return s.Delete(meta.Name, &metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{UID: &existing.UID},
+		})
+	}
+
+	setManagedKeys(&existing.ObjectMeta, "", "")
+	existing.OwnerReferences = withoutOwner(existing.OwnerReferences, meta)
+	_, err = s.Update(existing)
+	return err
+}
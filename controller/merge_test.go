@@ -0,0 +1,265 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+// mergedSecret returns an existing secret with the given managed keys.
+func mergedSecret(managed string, data map[string]string) *v1.Secret {
+	secret := existingSecret(data)
+	secret.Annotations = map[string]string{primitives.AnnotationManagedKeys: managed}
+
+	return secret
+}
+
+// managedSecret returns an existing secret with the given keys managed by the
+// given object.
+func managedSecret(owner, managed string, data map[string]string) *v1.Secret {
+	secret := mergedSecret(managed, data)
+	secret.Annotations[primitives.AnnotationManagedBy] = owner
+
+	return secret
+}
+
+func TestMergeSecretData(t *testing.T) {
+	live := mergedSecret("OLD,USERNAME", map[string]string{"OLD": "old", "USERNAME": "old-user", "tls.crt": "cert"})
+	desired := existingSecret(map[string]string{"USERNAME": "prod-user", "PASSWORD": "prod-pass"})
+
+	data := MergeSecretData(live, desired)
+
+	expected := map[string]string{"USERNAME": "prod-user", "PASSWORD": "prod-pass", "tls.crt": "cert"}
+	if len(data) != len(expected) {
+		t.Errorf("expected %d keys, got %d: %v", len(expected), len(data), data)
+	}
+	for k, v := range expected {
+		if got := string(data[k]); got != v {
+			t.Errorf("expected %s to eq %q, got %q", k, v, got)
+		}
+	}
+}
+
+func TestCreateOrUpdateProject_merge(t *testing.T) {
+	spec := &primitives.ProjectSpec{
+		Name:          "production",
+		WriteStrategy: primitives.WriteStrategyMerge,
+		Resources: []*primitives.ResourceSpec{
+			{Name: "db", Credentials: []*primitives.CredentialSpec{{Key: "USERNAME"}}},
+		},
+	}
+
+	tlsSecret := existingSecret(map[string]string{"tls.crt": "cert", "tls.key": "key"})
+	tlsSecret.Type = v1.SecretTypeTLS
+
+	tcs := []struct {
+		scenario   string
+		objects    []runtime.Object
+		expected   map[string]string
+		secretType v1.SecretType
+	}{
+		{
+			scenario: "creates the secret",
+			expected: map[string]string{"USERNAME": "prod-user"},
+		},
+		{
+			scenario: "keeps the keys it doesn't manage",
+			objects: []runtime.Object{
+				mergedSecret("OLD", map[string]string{"OLD": "old", "tls.crt": "cert"}),
+			},
+			expected: map[string]string{"USERNAME": "prod-user", "tls.crt": "cert"},
+		},
+		{
+			scenario: "merges into a secret it already controls",
+			objects: []runtime.Object{
+				controlledSecret("project-uid", map[string]string{"USERNAME": "old-user"}),
+			},
+			expected: map[string]string{"USERNAME": "prod-user"},
+		},
+		{
+			scenario:   "merges into a secret of another type",
+			objects:    []runtime.Object{tlsSecret},
+			expected:   map[string]string{"USERNAME": "prod-user", "tls.crt": "cert", "tls.key": "key"},
+			secretType: v1.SecretTypeTLS,
+		},
+		{
+			scenario: "merges into a secret it already manages",
+			objects: []runtime.Object{
+				managedSecret("Project/creds", "OLD", map[string]string{"OLD": "old"}),
+			},
+			expected: map[string]string{"USERNAME": "prod-user"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			project := testProject(spec)
+			c, kc, crds := newTestController(tc.objects, []runtime.Object{project})
+
+			c.createOrUpdateProject(project)
+
+			secret, err := kc.CoreV1().Secrets("default").Get("creds", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("expected no error getting the secret, got %q", err)
+			}
+
+			if len(secret.Data) != len(tc.expected) {
+				t.Errorf("expected %d keys, got %d: %v", len(tc.expected), len(secret.Data), secret.Data)
+			}
+			for k, v := range tc.expected {
+				if got := string(secret.Data[k]); got != v {
+					t.Errorf("expected %s to eq %q, got %q", k, v, got)
+				}
+			}
+
+			if got := secret.Annotations[primitives.AnnotationManagedKeys]; got != "USERNAME" {
+				t.Errorf("expected the managed keys to eq %q, got %q", "USERNAME", got)
+			}
+			if got := secret.Annotations[primitives.AnnotationManagedBy]; got != "Project/creds" {
+				t.Errorf("expected the keys to be managed by %q, got %q", "Project/creds", got)
+			}
+			if len(secret.OwnerReferences) != 0 {
+				t.Errorf("expected a merged secret to have no owners, got %v", secret.OwnerReferences)
+			}
+
+			secretType := tc.secretType
+			if secretType == "" {
+				secretType = v1.SecretTypeOpaque
+			}
+			if secret.Type != secretType {
+				t.Errorf("expected the secret type to eq %q, got %q", secretType, secret.Type)
+			}
+
+			updated, err := crds.ManifoldV1().Projects("default").Get("creds", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("expected no error getting the project, got %q", err)
+			}
+			expectReady(t, &updated.Status, v1.ConditionTrue)
+			if len(updated.Finalizers) != 1 || updated.Finalizers[0] != primitives.FinalizerSecret {
+				t.Errorf("expected the finalizers to eq [%s], got %v", primitives.FinalizerSecret, updated.Finalizers)
+			}
+		})
+	}
+}
+
+func TestCreateOrUpdateProject_mergeConflict(t *testing.T) {
+	project := testProject(&primitives.ProjectSpec{
+		Name:          "production",
+		WriteStrategy: primitives.WriteStrategyMerge,
+		Resources: []*primitives.ResourceSpec{
+			{Name: "db", Credentials: []*primitives.CredentialSpec{{Key: "USERNAME"}}},
+		},
+	})
+
+	tcs := []struct {
+		scenario string
+		existing *v1.Secret
+		reason   string
+	}{
+		{
+			scenario: "refuses a secret whose keys are managed by another object",
+			existing: managedSecret("Resource/creds", "URL", map[string]string{"URL": "redis://prod"}),
+			reason:   "ManagedByOtherObject",
+		},
+		{
+			scenario: "refuses to overwrite a key it doesn't manage",
+			existing: existingSecret(map[string]string{"USERNAME": "admin", "tls.crt": "cert"}),
+			reason:   "UnmanagedKeys",
+		},
+		{
+			scenario: "refuses to overwrite a key next to the ones it manages",
+			existing: managedSecret("Project/creds", "PASSWORD", map[string]string{"USERNAME": "admin", "PASSWORD": "prod-pass"}),
+			reason:   "UnmanagedKeys",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			c, kc, crds := newTestController([]runtime.Object{tc.existing.DeepCopy()}, []runtime.Object{project})
+
+			c.createOrUpdateProject(project)
+
+			secret, err := kc.CoreV1().Secrets("default").Get("creds", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("expected no error getting the secret, got %q", err)
+			}
+			if !reflect.DeepEqual(secret.Data, tc.existing.Data) || !reflect.DeepEqual(secret.Annotations, tc.existing.Annotations) {
+				t.Errorf("expected the secret to be left alone, got %v %v", secret.Annotations, secret.Data)
+			}
+
+			updated, err := crds.ManifoldV1().Projects("default").Get("creds", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("expected no error getting the project, got %q", err)
+			}
+			cond := updated.Status.Condition(primitives.ConditionSecretConflict)
+			if cond == nil || cond.Reason != tc.reason {
+				t.Errorf("expected a %s condition with reason %q, got %v", primitives.ConditionSecretConflict, tc.reason, cond)
+			}
+
+			// The refused key is never listed as managed, so deleting the
+			// Project leaves it alone.
+			c.deleteProject(project)
+			if secret, err := kc.CoreV1().Secrets("default").Get("creds", metav1.GetOptions{}); err != nil || string(secret.Data["USERNAME"]) != string(tc.existing.Data["USERNAME"]) {
+				t.Errorf("expected the deletion to leave the keys it doesn't manage, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDelete_merge(t *testing.T) {
+	project := testProject(&primitives.ProjectSpec{Name: "production", WriteStrategy: primitives.WriteStrategyMerge})
+
+	t.Run("removes the managed keys", func(t *testing.T) {
+		c, kc, _ := newTestController([]runtime.Object{
+			mergedSecret("USERNAME", map[string]string{"USERNAME": "prod-user", "tls.crt": "cert"}),
+		}, nil)
+
+		c.deleteProject(project)
+
+		secret, err := kc.CoreV1().Secrets("default").Get("creds", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("expected the secret to be kept, got %q", err)
+		}
+		if len(secret.Data) != 1 || string(secret.Data["tls.crt"]) != "cert" {
+			t.Errorf("expected only tls.crt to be left, got %v", secret.Data)
+		}
+		if _, ok := secret.Annotations[primitives.AnnotationManagedKeys]; ok {
+			t.Errorf("expected the managed keys annotation to be removed")
+		}
+	})
+
+	t.Run("leaves keys managed by another object", func(t *testing.T) {
+		c, kc, _ := newTestController([]runtime.Object{
+			managedSecret("Resource/creds", "USERNAME", map[string]string{"USERNAME": "prod-user"}),
+		}, nil)
+
+		c.deleteProject(project)
+
+		secret, err := kc.CoreV1().Secrets("default").Get("creds", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("expected the secret to be kept, got %q", err)
+		}
+		if string(secret.Data["USERNAME"]) != "prod-user" {
+			t.Errorf("expected the keys to be kept, got %v", secret.Data)
+		}
+	})
+
+	t.Run("deletes a secret with only managed keys", func(t *testing.T) {
+		c, kc, _ := newTestController([]runtime.Object{
+			mergedSecret("USERNAME", map[string]string{"USERNAME": "prod-user"}),
+		}, nil)
+
+		c.deleteProject(project)
+
+		_, err := kc.CoreV1().Secrets("default").Get("creds", metav1.GetOptions{})
+		if !apierrors.IsNotFound(err) {
+			t.Errorf("expected the secret to be deleted, got %v", err)
+		}
+	})
+}
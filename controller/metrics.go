@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/manifoldco/kubernetes-credentials/throttle"
+)
+
+const metricsNamespace = "manifold_credentials"
+
+// The kinds we report metrics for.
+const (
+	kindProject  = "project"
+	kindResource = "resource"
+)
+
+var (
+	reconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "reconcile_total",
+		Help:      "Number of reconciles per kind and result.",
+	}, []string{"kind", "result"})
+
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "reconcile_duration_seconds",
+		Help:      "Duration of reconciles per kind and result.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"kind", "result"})
+
+	apiRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "manifold_api_requests_total",
+		Help:      "Number of Manifold API calls per operation and result code.",
+	}, []string{"operation", "code"})
+
+	apiRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "manifold_api_request_duration_seconds",
+		Help:      "Latency of Manifold API calls per operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	circuitBreakerOpen = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "manifold_api_circuit_breaker_open",
+		Help:      "Whether the Manifold API circuit breaker is open (1) or closed (0).",
+	})
+
+	secretWritesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "secret_writes_total",
+		Help:      "Number of secrets written or skipped because they were up to date.",
+	}, []string{"kind", "result"})
+
+	workloadRolloutsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "workload_rollouts_total",
+		Help:      "Number of workloads rolled out because a secret they use changed.",
+	}, []string{"kind"})
+
+	decodeFailuresTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "decode_failures_total",
+		Help:      "Number of credential values that could not be decoded.",
+	})
+
+	managedObjects = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "managed_objects",
+		Help:      "Number of Projects and Resources managed by the controller.",
+	}, []string{"kind"})
+
+	lastSuccessfulSync = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "last_successful_sync_timestamp_seconds",
+		Help:      "Unix timestamp of the last successful sync per Project or Resource.",
+	}, []string{"kind", "namespace", "name"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		reconcileTotal,
+		reconcileDuration,
+		apiRequestsTotal,
+		apiRequestDuration,
+		circuitBreakerOpen,
+		secretWritesTotal,
+		workloadRolloutsTotal,
+		decodeFailuresTotal,
+		managedObjects,
+		lastSuccessfulSync,
+	)
+}
+
+// observeReconcile records the result of a reconcile which started at the
+// given time. It is meant to be deferred with a pointer to the reconcile's
+// success flag.
+func observeReconcile(kind string, meta *metav1.ObjectMeta, start time.Time, synced *bool) {
+	result := "error"
+	if *synced {
+		result = "success"
+		lastSuccessfulSync.WithLabelValues(kind, meta.Namespace, meta.Name).SetToCurrentTime()
+	}
+
+	reconcileTotal.WithLabelValues(kind, result).Inc()
+	reconcileDuration.WithLabelValues(kind, result).Observe(time.Since(start).Seconds())
+}
+
+// forgetObject removes the per object metrics of a deleted Project or
+// Resource.
+func forgetObject(kind string, meta *metav1.ObjectMeta) {
+	lastSuccessfulSync.DeleteLabelValues(kind, meta.Namespace, meta.Name)
+}
+
+// observeAPICall records a Manifold API call which started at the given time.
+func observeAPICall(operation string, start time.Time, err error) {
+	apiRequestsTotal.WithLabelValues(operation, errorCode(err)).Inc()
+	apiRequestDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
+// statusCoder is implemented by errors that carry the HTTP status code of the
+// failed request.
+type statusCoder interface {
+	StatusCode() int
+}
+
+// errorCode returns the code label for the result of a Manifold API call.
+func errorCode(err error) string {
+	if err == nil {
+		return "ok"
+	}
+
+	if throttle.IsOpen(err) {
+		return "circuit_open"
+	}
+
+	if sc, ok := err.(statusCoder); ok {
+		return fmt.Sprintf("%d", sc.StatusCode())
+	}
+
+	return "error"
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+// errPoliciesNotSynced is used when an object is reconciled before the
+// CredentialPolicies have been loaded. We can't tell if the object is allowed
+// at that point, so we don't load any credentials for it.
+var errPoliciesNotSynced = errors.New("credential policies have not been synced yet")
+
+// policyDeniedError is used when the CredentialPolicies don't allow a namespace
+// to load the requested credentials.
+type policyDeniedError struct {
+	reason  string
+	message string
+}
+
+func (e *policyDeniedError) Error() string {
+	return e.message
+}
+
+// checkPolicy verifies that the given namespace is allowed to load the
+// requested credentials with a client bound to the given team. A request for
+// another team than the client's is denied, as the client would load the
+// credentials of its own team regardless. When no CredentialPolicies exist,
+// everything else is allowed. As soon as one policy exists, a namespace can
+// only load credentials that are allowed by at least one of the policies that
+// apply to it.
+func (c *Controller) checkPolicy(l *log.Entry, namespace, team string, req primitives.CredentialRequest) error {
+	if team != "" && req.Team != "" && req.Team != team {
+		return &policyDeniedError{
+			reason:  "TeamMismatch",
+			message: fmt.Sprintf("team '%s' was requested but the client is bound to team '%s'", req.Team, team),
+		}
+	}
+	req.Team = team
+
+	if c.policies == nil || c.policiesSynced == nil || !c.policiesSynced() {
+		return errPoliciesNotSynced
+	}
+
+	all, err := c.policies.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	var policies []*primitives.CredentialPolicy
+	var needLabels bool
+	for _, policy := range all {
+		if policy.Spec == nil {
+			continue
+		}
+
+		policies = append(policies, policy)
+		if policy.Spec.NamespaceSelector != nil {
+			needLabels = true
+		}
+	}
+
+	if len(policies) == 0 {
+		return nil
+	}
+
+	var nsLabels map[string]string
+	if needLabels {
+		ns, err := c.namespaces.Get(namespace)
+		if err != nil {
+			return fmt.Errorf("could not get namespace '%s': %s", namespace, err)
+		}
+		nsLabels = ns.Labels
+	}
+
+	var applied bool
+	for _, policy := range policies {
+		applies, err := policy.Spec.AppliesTo(namespace, nsLabels)
+		if err != nil {
+			l.WithField("policy", policy.Name).WithError(err).Error("could not evaluate credential policy")
+			continue
+		}
+
+		if !applies {
+			continue
+		}
+
+		applied = true
+		if policy.Spec.Allows(req) {
+			return nil
+		}
+	}
+
+	if !applied {
+		return &policyDeniedError{
+			reason:  "NoMatchingPolicy",
+			message: fmt.Sprintf("no credential policy applies to namespace '%s'", namespace),
+		}
+	}
+
+	return &policyDeniedError{
+		reason:  "NotAllowed",
+		message: fmt.Sprintf("no credential policy allows namespace '%s' to load team '%s', project '%s', resources %v", namespace, req.Team, req.Project, req.Resources),
+	}
+}
+
+// policyStatus updates the PolicyDenied condition of the given status based on
+// the result of a policy check and reports whether the status changed. Errors
+// that don't tell us whether the object is allowed leave the status as is.
+func policyStatus(status *primitives.Status, err error) bool {
+	if err == nil {
+		return status.RemoveCondition(primitives.ConditionPolicyDenied)
+	}
+
+	denied, ok := err.(*policyDeniedError)
+	if !ok {
+		return false
+	}
+
+	return status.SetCondition(primitives.Condition{
+		Type:    primitives.ConditionPolicyDenied,
+		Status:  v1.ConditionTrue,
+		Reason:  denied.reason,
+		Message: denied.message,
+	})
+}
@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+func TestCreateOrUpdateProject_policy(t *testing.T) {
+	acct, acctSecret := accountObjects()
+	policy := &primitives.CredentialPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "teams"},
+		Spec: &primitives.CredentialPolicySpec{
+			Namespaces: []string{"default"},
+			Teams:      []string{"other"},
+		},
+	}
+
+	tcs := []struct {
+		scenario string
+		spec     *primitives.ProjectSpec
+		policies []runtime.Object
+		reason   string
+	}{
+		{
+			scenario: "rejects a team the client isn't bound to",
+			spec:     &primitives.ProjectSpec{Name: "production", Team: "other"},
+			reason:   "TeamMismatch",
+		},
+		{
+			scenario: "rejects a team the account isn't bound to",
+			spec:     &primitives.ProjectSpec{Name: "staging", Team: "manifold", Account: "other"},
+			reason:   "TeamMismatch",
+		},
+		{
+			scenario: "checks the team of the default client",
+			spec:     &primitives.ProjectSpec{Name: "production"},
+			policies: []runtime.Object{policy},
+			reason:   "NotAllowed",
+		},
+		{
+			scenario: "checks the team of the account",
+			spec:     &primitives.ProjectSpec{Name: "staging", Account: "other"},
+			policies: []runtime.Object{policy},
+		},
+		{
+			scenario: "allows the team the client is bound to",
+			spec:     &primitives.ProjectSpec{Name: "staging", Team: "other", Account: "other"},
+			policies: []runtime.Object{policy},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			project := testProject(tc.spec)
+			crdObjects := append([]runtime.Object{acct, project}, tc.policies...)
+			c, kc, crds := newTestController([]runtime.Object{acctSecret}, crdObjects)
+
+			c.createOrUpdateProject(project)
+
+			updated, err := crds.ManifoldV1().Projects("default").Get("creds", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("expected no error getting the project, got %q", err)
+			}
+
+			cond := updated.Status.Condition(primitives.ConditionPolicyDenied)
+			if tc.reason == "" {
+				if cond != nil {
+					t.Errorf("expected no %s condition, got %q", primitives.ConditionPolicyDenied, cond.Message)
+				}
+				expectSecret(t, kc, "project-uid", map[string]string{"USERNAME": "other-user", "PASSWORD": "other-pass"})
+				return
+			}
+
+			if cond == nil || cond.Status != v1.ConditionTrue {
+				t.Fatalf("expected the %s condition to be set", primitives.ConditionPolicyDenied)
+			}
+			if cond.Reason != tc.reason {
+				t.Errorf("expected the reason to eq %q, got %q", tc.reason, cond.Reason)
+			}
+			if _, err := kc.CoreV1().Secrets("default").Get("creds", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+				t.Errorf("expected no secret, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateOrUpdateProject_namespaceSelector(t *testing.T) {
+	policy := &primitives.CredentialPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "production"},
+		Spec: &primitives.CredentialPolicySpec{
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"env": "production"},
+			},
+			Projects: []string{"production"},
+		},
+	}
+
+	tcs := []struct {
+		scenario string
+		labels   map[string]string
+		reason   string
+	}{
+		{scenario: "allows a namespace matching the selector", labels: map[string]string{"env": "production"}},
+		{scenario: "denies a namespace not matching the selector", labels: map[string]string{"env": "dev"}, reason: "NoMatchingPolicy"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: tc.labels}}
+			project := testProject(&primitives.ProjectSpec{Name: "production"})
+			c, kc, crds := newTestController([]runtime.Object{ns}, []runtime.Object{project, policy})
+
+			c.createOrUpdateProject(project)
+
+			for _, action := range kc.Actions() {
+				if action.GetResource().Resource == "namespaces" {
+					t.Errorf("expected the namespace to be read from the cache, got a %s request", action.GetVerb())
+				}
+			}
+
+			updated, err := crds.ManifoldV1().Projects("default").Get("creds", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("expected no error getting the project, got %q", err)
+			}
+
+			cond := updated.Status.Condition(primitives.ConditionPolicyDenied)
+			if tc.reason == "" {
+				if cond != nil {
+					t.Errorf("expected no %s condition, got %q", primitives.ConditionPolicyDenied, cond.Message)
+				}
+				return
+			}
+			if cond == nil || cond.Reason != tc.reason {
+				t.Errorf("expected the %s condition with reason %q, got %v", primitives.ConditionPolicyDenied, tc.reason, cond)
+			}
+		})
+	}
+}
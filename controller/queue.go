@@ -0,0 +1,103 @@
+package controller
+
+import (
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+// workers is the number of Projects and Resources that are reconciled at the
+// same time. The queue never hands out the same object twice at once.
+const workers = 2
+
+// queueItem is a Project or Resource waiting to be reconciled. Deleted objects
+// are no longer in the informer caches, so they're queued with their last
+// known state.
+type queueItem struct {
+	kind    string
+	key     string
+	deleted interface{}
+}
+
+// enqueue queues the given object of the given kind. The event handlers only
+// queue objects, they're reconciled by the workers once the caches have
+// synced.
+func (c *Controller) enqueue(kind string, obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		log.WithError(err).Error("could not get the key of an object")
+		return
+	}
+
+	c.queue.Add(queueItem{kind: kind, key: key})
+}
+
+// enqueueDeleted queues the given deleted object of the given kind.
+func (c *Controller) enqueueDeleted(kind string, obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		log.WithError(err).Error("could not get the key of a deleted object")
+		return
+	}
+
+	c.queue.Add(queueItem{kind: kind, key: key, deleted: obj})
+}
+
+// work reconciles queued objects until the queue is shut down.
+func (c *Controller) work() {
+	for c.processNextItem() {
+	}
+}
+
+// processNextItem reconciles the next queued object and reports whether the
+// queue is still running. Failed reconciles aren't requeued; they're retried
+// on the next resync.
+func (c *Controller) processNextItem() bool {
+	obj, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(obj)
+
+	item := obj.(queueItem)
+	switch item.kind {
+	case kindProject:
+		if project, ok := item.deleted.(*primitives.Project); ok {
+			c.deleteProject(project)
+			return true
+		}
+
+		namespace, name, _ := cache.SplitMetaNamespaceKey(item.key)
+		project, err := c.projects.Projects(namespace).Get(name)
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				log.WithError(err).WithField("key", item.key).Error("could not get the queued project")
+			}
+			return true
+		}
+		c.createOrUpdateProject(project)
+	case kindResource:
+		if resource, ok := item.deleted.(*primitives.Resource); ok {
+			c.deleteResource(resource)
+			return true
+		}
+
+		namespace, name, _ := cache.SplitMetaNamespaceKey(item.key)
+		resource, err := c.resources.Resources(namespace).Get(name)
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				log.WithError(err).WithField("key", item.key).Error("could not get the queued resource")
+			}
+			return true
+		}
+		c.createOrUpdateResource(resource)
+	}
+
+	return true
+}
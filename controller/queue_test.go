@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+func TestQueue(t *testing.T) {
+	spec := &primitives.ProjectSpec{
+		Name: "production",
+		Resources: []*primitives.ResourceSpec{
+			{Name: "db", Credentials: []*primitives.CredentialSpec{{Key: "USERNAME"}}},
+		},
+	}
+
+	t.Run("only reconciles queued objects in the workers", func(t *testing.T) {
+		project := testProject(spec)
+		c, kc, _ := newTestController(nil, []runtime.Object{project})
+
+		c.onProjectAdd(project)
+		c.onProjectUpdate(project, project)
+
+		if _, err := kc.CoreV1().Secrets("default").Get("creds", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+			t.Fatalf("expected no secret before the queue is processed, got %v", err)
+		}
+		if n := c.queue.Len(); n != 1 {
+			t.Fatalf("expected the events to be queued once, got %d items", n)
+		}
+
+		c.processNextItem()
+
+		expectSecret(t, kc, "project-uid", map[string]string{"USERNAME": "prod-user"})
+	})
+
+	t.Run("skips objects which are no longer cached", func(t *testing.T) {
+		c, kc, _ := newTestController(nil, nil)
+
+		c.onProjectAdd(testProject(spec))
+		c.processNextItem()
+
+		if _, err := kc.CoreV1().Secrets("default").Get("creds", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+			t.Errorf("expected no secret, got %v", err)
+		}
+	})
+
+	t.Run("deletes the secret of a tombstoned object", func(t *testing.T) {
+		c, kc, _ := newTestController([]runtime.Object{
+			controlledSecret("project-uid", map[string]string{"USERNAME": "prod-user"}),
+		}, nil)
+
+		c.onProjectDelete(cache.DeletedFinalStateUnknown{Key: "default/creds", Obj: testProject(spec)})
+		c.processNextItem()
+
+		if _, err := kc.CoreV1().Secrets("default").Get("creds", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+			t.Errorf("expected the secret to be deleted, got %v", err)
+		}
+	})
+
+	t.Run("stops once shut down", func(t *testing.T) {
+		c, _, _ := newTestController(nil, nil)
+
+		c.queue.ShutDown()
+
+		if c.processNextItem() {
+			t.Error("expected the queue to be shut down")
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/manifoldco/kubernetes-credentials/logging"
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+// ProjectValues returns the credential values of the Project, loaded from the
+// given source with the aliases, defaults and decoding the controller uses.
+// Values that can't be decoded are logged to the given entry and kept as is,
+// like the controller does.
+func ProjectValues(ctx context.Context, l *log.Entry, src CredentialSource, project *primitives.Project) (map[string][]byte, error) {
+	cmap, err := projectCredentials(ctx, src, project.Spec)
+	if err != nil {
+		return nil, err
+	}
+	defer logging.Redact(mapValues(cmap)...)()
+
+	return projectSecretData(l, project.Spec, cmap), nil
+}
+
+// ResourceValues returns the credential values of the Resource, loaded from
+// the given source with the aliases, defaults and decoding the controller
+// uses.
+func ResourceValues(ctx context.Context, l *log.Entry, src CredentialSource, resource *primitives.Resource) (map[string][]byte, error) {
+	cmap, err := resourceCredentials(ctx, src, resource.Spec)
+	if err != nil {
+		return nil, err
+	}
+	defer logging.Redact(mapValues(cmap)...)()
+
+	return resourceSecretData(l, resource.Spec, cmap), nil
+}
+
+// ProjectSecret returns the secret the controller writes for the Project, with
+// the credentials loaded from the given source. A secret which is merged only
+// holds the keys of the Project; use MergeSecretData to merge it into the live
+// secret.
+func ProjectSecret(ctx context.Context, l *log.Entry, src CredentialSource, project *primitives.Project) (*v1.Secret, error) {
+	data, err := ProjectValues(ctx, l, src, project)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSecret(&project.ObjectMeta, data, project.Spec.SecretType(), projectControllerKind, project.Spec.MergeStrategy())
+}
+
+// ResourceSecret returns the secret the controller writes for the Resource,
+// with the credentials loaded from the given source.
+func ResourceSecret(ctx context.Context, l *log.Entry, src CredentialSource, resource *primitives.Resource) (*v1.Secret, error) {
+	data, err := ResourceValues(ctx, l, src, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSecret(&resource.ObjectMeta, data, resource.Spec.SecretType(), resourceControllerKind, resource.Spec.MergeStrategy())
+}
@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+// workload is a Deployment, StatefulSet or DaemonSet which can be rolled out
+// by patching its pod template.
+type workload struct {
+	kind     string
+	meta     *metav1.ObjectMeta
+	template *v1.PodTemplateSpec
+	patch    func(data []byte) error
+}
+
+// watchWorkloads keeps a cache of the Deployments, StatefulSets and DaemonSets
+// when rollouts are enabled, as they're compared with their secrets on every
+// reconcile.
+func (c *Controller) watchWorkloads(ctx context.Context) error {
+	if !c.rollout {
+		return nil
+	}
+
+	apps := c.kinformers.Apps().V1()
+	c.run(ctx, apps.Deployments().Informer())
+	c.run(ctx, apps.StatefulSets().Informer())
+	c.run(ctx, apps.DaemonSets().Informer())
+	return nil
+}
+
+// rolloutWorkloads rolls out the workloads in the namespace of the secret that
+// use it, after its data changed, by setting the content hash of the secret
+// as an annotation on their pod template. Every secret has its own annotation,
+// so a workload which uses several secrets is only rolled out for the one that
+// changed. A rollout which fails isn't retried until the data changes again.
+// Workloads use a secret when they reference it in their pod spec, or list it
+// in their secrets annotation.
+func (c *Controller) rolloutWorkloads(l *log.Entry, secret *v1.Secret) {
+	if !c.rollout {
+		return
+	}
+
+	workloads, err := c.workloads(secret.Namespace)
+	if err != nil {
+		l.WithError(err).Error("could not list workloads to roll out")
+		return
+	}
+
+	key := secretHashAnnotation(secret.Name)
+	hash := secretHash(secret)
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{
+						key: hash,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		l.WithError(err).Error("could not build the rollout patch")
+		return
+	}
+
+	for _, w := range workloads {
+		if !usesSecret(w.meta, &w.template.Spec, secret.Name) || w.template.Annotations[key] == hash {
+			continue
+		}
+
+		wl := l.WithField("workload_kind", w.kind).WithField("workload_name", w.meta.Name)
+		if err := w.patch(patch); err != nil {
+			wl.WithError(err).Error("could not roll out workload")
+			continue
+		}
+
+		workloadRolloutsTotal.WithLabelValues(strings.ToLower(w.kind)).Inc()
+		wl.Info("rolled out workload after its secret changed")
+	}
+}
+
+// workloads lists the Deployments, StatefulSets and DaemonSets in the given
+// namespace from the informer caches. They're only patched through the API.
+func (c *Controller) workloads(namespace string) ([]workload, error) {
+	apps := c.kc.AppsV1()
+	cached := c.kinformers.Apps().V1()
+	var workloads []workload
+
+	deployments, err := cached.Deployments().Lister().Deployments(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deployments {
+		d := d
+		workloads = append(workloads, workload{
+			kind:     "Deployment",
+			meta:     &d.ObjectMeta,
+			template: &d.Spec.Template,
+			patch: func(data []byte) error {
+				_, err := apps.Deployments(namespace).Patch(d.Name, types.StrategicMergePatchType, data)
+				return err
+			},
+		})
+	}
+
+	statefulSets, err := cached.StatefulSets().Lister().StatefulSets(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range statefulSets {
+		s := s
+		workloads = append(workloads, workload{
+			kind:     "StatefulSet",
+			meta:     &s.ObjectMeta,
+			template: &s.Spec.Template,
+			patch: func(data []byte) error {
+				_, err := apps.StatefulSets(namespace).Patch(s.Name, types.StrategicMergePatchType, data)
+				return err
+			},
+		})
+	}
+
+	daemonSets, err := cached.DaemonSets().Lister().DaemonSets(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, ds := range daemonSets {
+		ds := ds
+		workloads = append(workloads, workload{
+			kind:     "DaemonSet",
+			meta:     &ds.ObjectMeta,
+			template: &ds.Spec.Template,
+			patch: func(data []byte) error {
+				_, err := apps.DaemonSets(namespace).Patch(ds.Name, types.StrategicMergePatchType, data)
+				return err
+			},
+		})
+	}
+
+	return workloads, nil
+}
+
+// usesSecret returns whether a workload uses the named secret, either through
+// its secrets annotation or its pod spec.
+func usesSecret(meta *metav1.ObjectMeta, spec *v1.PodSpec, name string) bool {
+	for _, s := range strings.Split(meta.Annotations[primitives.AnnotationSecrets], ",") {
+		if strings.TrimSpace(s) == name {
+			return true
+		}
+	}
+
+	return podSpecReferences(spec, name)
+}
+
+// podSpecReferences returns whether the pod spec references the named secret
+// in a volume, an environment variable or an envFrom source.
+func podSpecReferences(spec *v1.PodSpec, name string) bool {
+	for _, vol := range spec.Volumes {
+		if vol.Secret != nil && vol.Secret.SecretName == name {
+			return true
+		}
+
+		if vol.Projected == nil {
+			continue
+		}
+		for _, src := range vol.Projected.Sources {
+			if src.Secret != nil && src.Secret.Name == name {
+				return true
+			}
+		}
+	}
+
+	containers := append(append([]v1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, ct := range containers {
+		for _, env := range ct.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil && env.ValueFrom.SecretKeyRef.Name == name {
+				return true
+			}
+		}
+
+		for _, env := range ct.EnvFrom {
+			if env.SecretRef != nil && env.SecretRef.Name == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// secretHashAnnotation returns the annotation which holds the content hash of
+// the named secret. The name part of an annotation key is limited to 63
+// characters, so longer secret names are cut short and suffixed with a hash of
+// the full name to keep them apart.
+func secretHashAnnotation(name string) string {
+	const max = 63 - len("secret-hash.")
+	if len(name) > max {
+		sum := sha256.Sum256([]byte(name))
+		name = name[:max-17] + "-" + hex.EncodeToString(sum[:])[:16]
+	}
+
+	return primitives.AnnotationSecretHashPrefix + name
+}
+
+// secretHash returns a hash of the data of the secret. It changes whenever the
+// data changes, without revealing it.
+func secretHash(secret *v1.Secret) string {
+	keys := make([]string, 0, len(secret.Data))
+	for k := range secret.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write(secret.Data[k])
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
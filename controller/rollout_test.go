@@ -0,0 +1,288 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+func TestUsesSecret(t *testing.T) {
+	tcs := []struct {
+		scenario    string
+		annotations map[string]string
+		spec        v1.PodSpec
+		expected    bool
+	}{
+		{
+			scenario: "without any reference",
+			spec: v1.PodSpec{
+				Containers: []v1.Container{{Name: "app"}},
+			},
+			expected: false,
+		},
+		{
+			scenario: "with an env var",
+			spec: v1.PodSpec{
+				Containers: []v1.Container{{
+					Name: "app",
+					Env: []v1.EnvVar{{
+						Name: "TOKEN",
+						ValueFrom: &v1.EnvVarSource{
+							SecretKeyRef: &v1.SecretKeySelector{
+								LocalObjectReference: v1.LocalObjectReference{Name: "creds"},
+								Key:                  "TOKEN",
+							},
+						},
+					}},
+				}},
+			},
+			expected: true,
+		},
+		{
+			scenario: "with envFrom in an init container",
+			spec: v1.PodSpec{
+				InitContainers: []v1.Container{{
+					Name: "migrate",
+					EnvFrom: []v1.EnvFromSource{{
+						SecretRef: &v1.SecretEnvSource{
+							LocalObjectReference: v1.LocalObjectReference{Name: "creds"},
+						},
+					}},
+				}},
+			},
+			expected: true,
+		},
+		{
+			scenario: "with a volume",
+			spec: v1.PodSpec{
+				Volumes: []v1.Volume{{
+					Name: "creds",
+					VolumeSource: v1.VolumeSource{
+						Secret: &v1.SecretVolumeSource{SecretName: "creds"},
+					},
+				}},
+			},
+			expected: true,
+		},
+		{
+			scenario: "with another secret",
+			spec: v1.PodSpec{
+				Volumes: []v1.Volume{{
+					Name: "other",
+					VolumeSource: v1.VolumeSource{
+						Secret: &v1.SecretVolumeSource{SecretName: "other"},
+					},
+				}},
+			},
+			expected: false,
+		},
+		{
+			scenario:    "with the secrets annotation",
+			annotations: map[string]string{primitives.AnnotationSecrets: "other, creds"},
+			expected:    true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			meta := metav1.ObjectMeta{Annotations: tc.annotations}
+			if uses := usesSecret(&meta, &tc.spec, "creds"); uses != tc.expected {
+				t.Errorf("Expected uses to be %t, got %t", tc.expected, uses)
+			}
+		})
+	}
+}
+
+func TestSecretHash(t *testing.T) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "creds"},
+		Data: map[string][]byte{
+			"TOKEN": []byte("token"),
+			"USER":  []byte("user"),
+		},
+	}
+
+	hash := secretHash(secret)
+	if strings.Contains(hash, "token") {
+		t.Errorf("Expected hash not to contain the values, got '%s'", hash)
+	}
+
+	if again := secretHash(secret); again != hash {
+		t.Errorf("Expected hash to be stable, got '%s' and '%s'", hash, again)
+	}
+
+	secret.Data["TOKEN"] = []byte("rotated")
+	if changed := secretHash(secret); changed == hash {
+		t.Error("Expected hash to change with the data")
+	}
+}
+
+func TestSecretHashAnnotation(t *testing.T) {
+	if key := secretHashAnnotation("creds"); key != primitives.AnnotationSecretHashPrefix+"creds" {
+		t.Errorf("Expected the key to end with the secret name, got '%s'", key)
+	}
+
+	long := strings.Repeat("a", 60)
+	key := secretHashAnnotation(long)
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		t.Errorf("Expected a valid annotation key, got '%s': %v", key, errs)
+	}
+	if other := secretHashAnnotation(long + "b"); other == key {
+		t.Errorf("Expected long names to get different keys, got '%s' twice", key)
+	}
+}
+
+func TestCreateOrUpdateProject_rollout(t *testing.T) {
+	project := testProject(&primitives.ProjectSpec{
+		Name: "production",
+		Resources: []*primitives.ResourceSpec{
+			{Name: "db", Credentials: []*primitives.CredentialSpec{{Key: "USERNAME"}}},
+		},
+	})
+
+	// The secret the test project writes, and the same secret before the
+	// credentials were rotated.
+	current := writtenSecret(t, project)
+	outdated := current.DeepCopy()
+	outdated.Data["USERNAME"] = []byte("old-user")
+
+	key := secretHashAnnotation("creds")
+	hash := secretHash(current)
+
+	tcs := []struct {
+		scenario    string
+		objects     []runtime.Object
+		annotations map[string]string
+		patched     bool
+	}{
+		{
+			scenario: "rolls out workloads when the data changes",
+			objects:  []runtime.Object{outdated},
+			patched:  true,
+		},
+		{
+			scenario:    "rolls out workloads which have seen an older secret",
+			objects:     []runtime.Object{outdated},
+			annotations: map[string]string{key: secretHash(outdated)},
+			patched:     true,
+		},
+		{
+			scenario:    "leaves workloads which have seen the changed data",
+			objects:     []runtime.Object{outdated},
+			annotations: map[string]string{key: hash},
+		},
+		{
+			scenario: "leaves workloads when the secret is created",
+		},
+		{
+			scenario: "leaves workloads when the secret is up to date",
+			objects:  []runtime.Object{current},
+		},
+		{
+			scenario:    "leaves workloads with an outdated hash when the secret is up to date",
+			objects:     []runtime.Object{current},
+			annotations: map[string]string{key: secretHash(outdated)},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			objects := append([]runtime.Object{testDeployment(tc.annotations, "creds")}, tc.objects...)
+			c, kc, _ := newTestController(objects, []runtime.Object{project})
+			c.rollout = true
+
+			c.createOrUpdateProject(project)
+
+			patches := deploymentPatches(kc)
+			if !tc.patched {
+				if len(patches) > 0 {
+					t.Errorf("expected the deployment not to be patched, got %s", patches)
+				}
+				return
+			}
+
+			if len(patches) != 1 || !strings.Contains(patches[0], key) || !strings.Contains(patches[0], hash) {
+				t.Errorf("expected the deployment to be patched once with %s: %s, got %s", key, hash, patches)
+			}
+		})
+	}
+
+	t.Run("only rolls out workloads using several secrets for the changed one", func(t *testing.T) {
+		other := project.DeepCopy()
+		other.Name = "other-creds"
+		other.UID = "other-uid"
+		otherSecret := writtenSecret(t, other)
+
+		annotations := map[string]string{
+			key:                                 secretHash(outdated),
+			secretHashAnnotation("other-creds"): secretHash(otherSecret),
+		}
+		objects := []runtime.Object{testDeployment(annotations, "creds", "other-creds"), outdated, otherSecret}
+		c, kc, _ := newTestController(objects, []runtime.Object{project, other})
+		c.rollout = true
+
+		for i := 0; i < 3; i++ {
+			c.createOrUpdateProject(project)
+			c.createOrUpdateProject(other)
+		}
+
+		patches := deploymentPatches(kc)
+		if len(patches) != 1 {
+			t.Fatalf("expected the deployment to be patched once, got %s", patches)
+		}
+		if strings.Contains(patches[0], "other-creds") || !strings.Contains(patches[0], key) {
+			t.Errorf("expected only the hash of the changed secret to be patched, got %s", patches[0])
+		}
+	})
+}
+
+// writtenSecret returns the secret the given test project writes.
+func writtenSecret(t *testing.T, project *primitives.Project) *v1.Secret {
+	t.Helper()
+
+	c, kc, _ := newTestController(nil, []runtime.Object{project})
+	c.createOrUpdateProject(project)
+
+	secret, err := kc.CoreV1().Secrets(project.Namespace).Get(project.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected no error getting the secret, got %q", err)
+	}
+
+	return secret
+}
+
+// testDeployment returns a Deployment which mounts the named secrets and has
+// the given pod template annotations.
+func testDeployment(annotations map[string]string, secrets ...string) *appsv1.Deployment {
+	d := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"}}
+	d.Spec.Template.Annotations = annotations
+	for _, name := range secrets {
+		d.Spec.Template.Spec.Volumes = append(d.Spec.Template.Spec.Volumes, v1.Volume{
+			Name:         name,
+			VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{SecretName: name}},
+		})
+	}
+
+	return d
+}
+
+// deploymentPatches returns the patches sent for Deployments.
+func deploymentPatches(kc *fake.Clientset) []string {
+	var patches []string
+	for _, action := range kc.Actions() {
+		if patch, ok := action.(k8stesting.PatchAction); ok && action.GetResource().Resource == "deployments" {
+			patches = append(patches, string(patch.GetPatch()))
+		}
+	}
+
+	return patches
+}
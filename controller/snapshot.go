@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+	"github.com/manifoldco/kubernetes-credentials/snapshot"
+	"github.com/manifoldco/kubernetes-credentials/throttle"
+)
+
+// snapshotKey returns the snapshot key for a credential request. Requests
+// through the default source are shared across namespaces, so a Project that
+// is recreated in a new namespace can still be served. Requests through a
+// ManifoldAccount are scoped to the account's namespace, so one namespace can
+// never be served credentials that were loaded with another's token.
+func snapshotKey(kind, namespace, account string, request ...interface{}) string {
+	scope := "default"
+	if account != "" {
+		scope = namespace + "/" + account
+	}
+
+	return snapshot.Key(append([]interface{}{kind, scope}, request...)...)
+}
+
+// withSnapshot records successfully fetched credentials in the snapshot, and
+// serves them from the snapshot when the credential source is unavailable,
+// unless a refresh was requested. Errors which aren't caused by an outage,
+// like a missing resource or a revoked token, are returned as is. The returned
+// entry is set when the credentials were served from the snapshot.
+func (c *Controller) withSnapshot(l *log.Entry, refresh bool, key string, cmap map[string]string, err error) (map[string]string, *snapshot.Entry, error) {
+	if c.snapshot == nil {
+		return cmap, nil, err
+	}
+
+	if err == nil {
+		c.snapshot.Put(key, cmap)
+		return cmap, nil, nil
+	}
+
+	if refresh || !throttle.Transient(err) {
+		return nil, nil, err
+	}
+
+	entry, ok := c.snapshot.Get(key)
+	if !ok {
+		return nil, nil, err
+	}
+
+	l.WithError(err).WithField("fetched_at", entry.FetchedAt).Warn("could not fetch credentials, serving them from the snapshot")
+	return entry.Values, entry, nil
+}
+
+// cacheStatus updates the ServedFromCache condition of the given status and
+// reports whether the status changed.
+func cacheStatus(status *primitives.Status, entry *snapshot.Entry) bool {
+	if entry == nil {
+		return status.RemoveCondition(primitives.ConditionServedFromCache)
+	}
+
+	return status.SetCondition(primitives.Condition{
+		Type:    primitives.ConditionServedFromCache,
+		Status:  v1.ConditionTrue,
+		Reason:  "SourceUnavailable",
+		Message: fmt.Sprintf("the credential source is unavailable, serving credentials fetched at %s", entry.FetchedAt.UTC().Format(time.RFC3339)),
+	})
+}
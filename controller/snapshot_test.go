@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/manifoldco/go-manifold"
+	merrors "github.com/manifoldco/go-manifold/errors"
+	"github.com/manifoldco/go-manifold/integrations"
+
+	"github.com/manifoldco/kubernetes-credentials/snapshot"
+	"github.com/manifoldco/kubernetes-credentials/throttle"
+)
+
+func TestWithSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "controller-snapshot")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tcs := []struct {
+		scenario string
+		err      error
+		refresh  bool
+		served   bool
+	}{
+		{scenario: "serves it when the API can't be reached", err: &url.Error{Op: "Get", URL: "http://api", Err: errors.New("connection refused")}, served: true},
+		{scenario: "serves it on server errors", err: manifold.NewError(merrors.InternalServerError, "down"), served: true},
+		{scenario: "serves it on proxy error pages", err: &url.Error{Op: "Get", URL: "http://api", Err: &throttle.StatusError{Code: 502, Status: "502 Bad Gateway"}}, served: true},
+		{scenario: "serves it while the circuit breaker is open", err: throttle.ErrOpen, served: true},
+		{scenario: "doesn't serve it for a missing resource", err: integrations.ErrResourceNotFound},
+		{scenario: "doesn't serve it for a missing default credential", err: integrations.ErrCredentialDefaultNotSet},
+		{scenario: "doesn't serve it for a rejected token", err: manifold.NewError(merrors.UnauthorizedError, "invalid token")},
+		{scenario: "doesn't serve it when a refresh was requested", err: throttle.ErrOpen, refresh: true},
+	}
+
+	for i, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			snap, err := snapshot.New(&snapshot.FileStore{Path: filepath.Join(dir, fmt.Sprintf("snapshot-%d", i))}, make([]byte, snapshot.KeySize), time.Hour)
+			if err != nil {
+				t.Fatalf("expected no error, got %q", err)
+			}
+
+			c, _, _ := newTestController(nil, nil)
+			c.snapshot = snap
+			l := log.NewEntry(log.StandardLogger())
+
+			if _, _, err := c.withSnapshot(l, false, "key", map[string]string{"USERNAME": "prod-user"}, nil); err != nil {
+				t.Fatalf("expected no error recording the snapshot, got %q", err)
+			}
+
+			cmap, entry, err := c.withSnapshot(l, tc.refresh, "key", nil, tc.err)
+			if !tc.served {
+				if err != tc.err {
+					t.Errorf("expected err to eq %q, got %v", tc.err, err)
+				}
+				if entry != nil {
+					t.Error("expected the credentials not to be served from the snapshot")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error, got %q", err)
+			}
+			if entry == nil || cmap["USERNAME"] != "prod-user" {
+				t.Errorf("expected the credentials to be served from the snapshot, got %v", cmap)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/manifoldco/go-manifold/integrations"
+	"github.com/manifoldco/go-manifold/integrations/primitives"
+)
+
+var _ CredentialSource = (*integrations.Client)(nil)
+
+// CredentialSource loads the credential values for Manifold resources. The
+// Manifold integrations client is the default implementation.
+type CredentialSource interface {
+	// GetResourcesCredentialValues returns the credential values for the given
+	// resources within a project, keyed by resource label. When no resources
+	// are given, the values of all resources in the project are returned.
+	GetResourcesCredentialValues(ctx context.Context, project *string, resources []*primitives.Resource) (map[string][]*primitives.CredentialValue, error)
+
+	// GetResourceCredentialValues returns the credential values for a single
+	// resource. When no project is given, the resource is looked up by its
+	// label alone.
+	GetResourceCredentialValues(ctx context.Context, project *string, resource *primitives.Resource) ([]*primitives.CredentialValue, error)
+}
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+// updateStatus applies the mutation to the status of the given Project or
+// Resource and writes the object back to the cluster if the status changed.
+// The object should be a copy of the one in the informer cache; it's updated
+// with the result so the status can be updated again within the same
+// reconcile.
+func (c *Controller) updateStatus(l *log.Entry, obj runtime.Object, status *primitives.Status, mutate func(*primitives.Status) bool) {
+	if !mutate(status) {
+		return
+	}
+
+	if err := c.update(obj); err != nil {
+		l.WithError(err).Error("could not update status")
+	}
+}
+
+// update writes the given Project or Resource back to the cluster and updates
+// it with the result.
+func (c *Controller) update(obj runtime.Object) error {
+	switch o := obj.(type) {
+	case *primitives.Project:
+		updated, err := c.crds.ManifoldV1().Projects(o.Namespace).Update(o)
+		if err != nil {
+			return err
+		}
+		*o = *updated
+	case *primitives.Resource:
+		updated, err := c.crds.ManifoldV1().Resources(o.Namespace).Update(o)
+		if err != nil {
+			return err
+		}
+		*o = *updated
+	default:
+		return fmt.Errorf("unsupported object %T", obj)
+	}
+
+	return nil
+}
+
+// specStatus updates the InvalidSpec condition of the given status with the
+// result of validating the spec, and reports whether the status changed.
+func specStatus(status *primitives.Status, err error) bool {
+	if err == nil {
+		return status.RemoveCondition(primitives.ConditionInvalidSpec)
+	}
+
+	return status.SetCondition(primitives.Condition{
+		Type:    primitives.ConditionInvalidSpec,
+		Status:  v1.ConditionTrue,
+		Reason:  "InvalidDeletionPolicy",
+		Message: err.Error(),
+	})
+}
+
+// readyStatus updates the Ready condition of the given status and reports
+// whether the status changed.
+func readyStatus(status *primitives.Status, synced bool) bool {
+	if synced {
+		return status.SetCondition(primitives.Condition{
+			Type:    primitives.ConditionReady,
+			Status:  v1.ConditionTrue,
+			Reason:  "Synced",
+			Message: "the secret is in sync",
+		})
+	}
+
+	return status.SetCondition(primitives.Condition{
+		Type:    primitives.ConditionReady,
+		Status:  v1.ConditionFalse,
+		Reason:  "SyncFailed",
+		Message: "the secret could not be synced, see the controller logs",
+	})
+}
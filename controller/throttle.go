@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/manifoldco/go-manifold/integrations/primitives"
+	"github.com/manifoldco/kubernetes-credentials/throttle"
+)
+
+// instrumentedSource records the calls of a credential source for the metrics
+// and health checks. The requests they make are guarded by the throttle of the
+// HTTP transport of the Manifold clients.
+type instrumentedSource struct {
+	src CredentialSource
+	c   *Controller
+}
+
+func (s *instrumentedSource) GetResourcesCredentialValues(ctx context.Context, project *string, resources []*primitives.Resource) (map[string][]*primitives.CredentialValue, error) {
+	var creds map[string][]*primitives.CredentialValue
+	err := s.call("GetResourcesCredentialValues", func() error {
+		var err error
+		creds, err = s.src.GetResourcesCredentialValues(ctx, project, resources)
+		return err
+	})
+	return creds, err
+}
+
+func (s *instrumentedSource) GetResourceCredentialValues(ctx context.Context, project *string, resource *primitives.Resource) ([]*primitives.CredentialValue, error) {
+	var creds []*primitives.CredentialValue
+	err := s.call("GetResourceCredentialValues", func() error {
+		var err error
+		creds, err = s.src.GetResourceCredentialValues(ctx, project, resource)
+		return err
+	})
+	return creds, err
+}
+
+func (s *instrumentedSource) call(operation string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	observeAPICall(operation, start, err)
+	s.c.recordAPICall(err)
+
+	return err
+}
+
+// instrumented wraps the given source to record its calls.
+func (c *Controller) instrumented(src CredentialSource) CredentialSource {
+	return &instrumentedSource{src: src, c: c}
+}
+
+// watchBreaker logs the state changes of the circuit breaker and reports them
+// as a metric.
+func watchBreaker(b *throttle.Breaker) {
+	if b == nil {
+		return
+	}
+
+	b.OnStateChange = func(from, to throttle.State) {
+		circuitBreakerOpen.Set(breakerOpenValue(to))
+
+		l := log.WithFields(log.Fields{
+			"breaker_state":          to.String(),
+			"breaker_previous_state": from.String(),
+		})
+		switch to {
+		case throttle.StateOpen:
+			l.Warn("Manifold API circuit breaker opened")
+		case throttle.StateHalfOpen:
+			l.Info("Probing the Manifold API")
+		default:
+			l.Info("Manifold API circuit breaker closed")
+		}
+	}
+}
+
+func breakerOpenValue(s throttle.State) float64 {
+	if s == throttle.StateClosed {
+		return 0
+	}
+
+	return 1
+}
@@ -8,7 +8,6 @@ import (
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/wait"
 
@@ -16,13 +15,21 @@ import (
 )
 
 // SchemeGroupVersion is the group version used to register these objects.
-var SchemeGroupVersion = schema.GroupVersion{
-	Group:   primitives.CRDGroup,
-	Version: primitives.CRDVersion,
-}
+var SchemeGroupVersion = primitives.SchemeGroupVersion
 
-// CreateCRD is a wrapper to create a CRD from scratch with a set of params.
+// CreateCRD is a wrapper to create a namespaced CRD from scratch with a set of
+// params.
 func CreateCRD(cs apiextensionsclient.Interface, name, plural, group, version string) error {
+	return createCRD(cs, name, plural, group, version, apiextv1beta1.NamespaceScoped)
+}
+
+// CreateClusterCRD is a wrapper to create a cluster scoped CRD from scratch
+// with a set of params.
+func CreateClusterCRD(cs apiextensionsclient.Interface, name, plural, group, version string) error {
+	return createCRD(cs, name, plural, group, version, apiextv1beta1.ClusterScoped)
+}
+
+func createCRD(cs apiextensionsclient.Interface, name, plural, group, version string, scope apiextv1beta1.ResourceScope) error {
 	fullName := plural + "." + group
 
 	crd := &apiextv1beta1.CustomResourceDefinition{
@@ -32,7 +39,7 @@ func CreateCRD(cs apiextensionsclient.Interface, name, plural, group, version st
 		Spec: apiextv1beta1.CustomResourceDefinitionSpec{
 			Group:   group,
 			Version: version,
-			Scope:   apiextv1beta1.NamespaceScoped,
+			Scope:   scope,
 			Names: apiextv1beta1.CustomResourceDefinitionNames{
 				Plural: plural,
 				Kind:   name,
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/manifoldco/kubernetes-credentials/client/clientset/versioned"
+	"github.com/manifoldco/kubernetes-credentials/controller"
+	"github.com/manifoldco/kubernetes-credentials/manifest"
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+// The exit codes of the diff subcommand, following diff(1).
+const (
+	diffExitSame    = 0
+	diffExitChanged = 1
+	diffExitError   = 2
+)
+
+// keyChange is a change to a single key of a secret.
+type keyChange struct {
+	Key string
+	Op  byte // '+' for added, '-' for removed and '~' for changed keys.
+	Old string
+	New string
+}
+
+// runDiff compares the secrets the controller would write for Projects and
+// Resources with the secrets in the cluster.
+func runDiff(args []string) int {
+	fs := newFlagSet("diff", "[<file, directory, project/<name> or resource/<name>>...]", "Compares the secrets the controller would write for the given Projects and Resources, or all of them in the namespace, with the secrets in the cluster.\nObjects which reference a ManifoldAccount load their credentials through it, like the controller.\nExits with 0 when there are no changes, 1 when there are and 2 on errors.")
+	credentialsFile := fs.String("credentials-file", "", "Load credentials from this local file or directory instead of the Manifold API.")
+	allNamespaces := fs.Bool("all-namespaces", false, "Compare the Projects and Resources of all namespaces when no arguments are given.")
+	o := &options{}
+	registerClusterFlags(fs, o)
+	if err := fs.Parse(args); err != nil {
+		return diffExitError
+	}
+
+	setupCommandLogging()
+	ctx := context.Background()
+
+	kc, crds, namespace, err := clusterClients(o)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return diffExitError
+	}
+
+	var objects []*manifest.Object
+	if fs.NArg() == 0 {
+		if *allNamespaces {
+			namespace = metav1.NamespaceAll
+		}
+		objects, err = listObjects(crds, namespace)
+	} else {
+		objects, err = loadObjects(fs.Args(), func() (versioned.Interface, string, error) {
+			return crds, namespace, nil
+		})
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return diffExitError
+	}
+
+	// Like the controller, the default source is optional when the objects
+	// reference ManifoldAccounts.
+	src, _, _, err := defaultSource(ctx, &options{credentialsFile: *credentialsFile})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return diffExitError
+	}
+
+	return diffObjects(ctx, os.Stdout, kc, clusterSources(kc, crds, src, newManifoldClient), objects, namespace)
+}
+
+// diffObjects writes the changes to the secrets of the objects and returns the
+// exit code of the diff subcommand. Objects without a namespace are compared
+// in the given namespace.
+func diffObjects(ctx context.Context, w io.Writer, kc kubernetes.Interface, sources sourceFunc, objects []*manifest.Object, namespace string) int {
+	code := diffExitSame
+	for _, obj := range objects {
+		if obj.Meta().Namespace == "" {
+			obj.Meta().Namespace = namespace
+		}
+
+		desired, err := desiredSecret(ctx, sources, obj)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s:%d: could not render %s '%s': %s\n", obj.File, obj.Line, obj.Kind(), obj.Meta().Name, err)
+			code = diffExitError
+			continue
+		}
+
+		live, err := kc.CoreV1().Secrets(desired.Namespace).Get(desired.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			live, err = nil, nil
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s:%d: could not get the secret of %s '%s': %s\n", obj.File, obj.Line, obj.Kind(), obj.Meta().Name, err)
+			code = diffExitError
+			continue
+		}
+
+		if live != nil && mergesSecret(obj) {
+			// The type of a secret can't be changed, so merging keeps it.
+			desired.Type = live.Type
+			desired.Data = controller.MergeSecretData(live, desired)
+		}
+
+		if printDiff(w, obj, live, desired) && code == diffExitSame {
+			code = diffExitChanged
+		}
+	}
+
+	return code
+}
+
+// listObjects returns all Projects and Resources in the namespace.
+func listObjects(crds versioned.Interface, namespace string) ([]*manifest.Object, error) {
+	projects, err := crds.ManifoldV1().Projects(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := crds.ManifoldV1().Resources(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []*manifest.Object
+	for _, p := range projects.Items {
+		objects = append(objects, &manifest.Object{File: "project/" + p.Name, Project: p})
+	}
+	for _, r := range resources.Items {
+		objects = append(objects, &manifest.Object{File: "resource/" + r.Name, Resource: r})
+	}
+
+	return objects, nil
+}
+
+// printDiff writes the changes between the live and desired secret of the
+// object, if there are any, and reports whether there were. A nil live
+// secret doesn't exist yet.
+func printDiff(w io.Writer, obj *manifest.Object, live, desired *v1.Secret) bool {
+	header := fmt.Sprintf("%s '%s' (secret %s/%s)", obj.Kind(), obj.Meta().Name, desired.Namespace, desired.Name)
+	if obj.Meta().Annotations[primitives.AnnotationPaused] == "true" {
+		header += ", paused"
+	}
+
+	if live == nil {
+		fmt.Fprintf(w, "%s: will be created\n", header)
+		for _, c := range diffData(nil, desired.Data) {
+			fmt.Fprintf(w, "  + %s\n", c.Key)
+		}
+		return true
+	}
+
+	changes := diffData(live.Data, desired.Data)
+	if len(changes) == 0 && live.Type == desired.Type {
+		return false
+	}
+
+	fmt.Fprintf(w, "%s:\n", header)
+	if live.Type != desired.Type {
+		fmt.Fprintf(w, "  type: %s -> %s\n", live.Type, desired.Type)
+	}
+	for _, c := range changes {
+		if c.Op == '~' {
+			fmt.Fprintf(w, "  ~ %s %s -> %s\n", c.Key, c.Old, c.New)
+			continue
+		}
+		fmt.Fprintf(w, "  %c %s\n", c.Op, c.Key)
+	}
+
+	return true
+}
+
+// diffData returns the changes from the live to the desired secret data,
+// ordered by key. Changed values are only included as hashes.
+func diffData(live, desired map[string][]byte) []keyChange {
+	var changes []keyChange
+	for k, v := range desired {
+		lv, ok := live[k]
+		switch {
+		case !ok:
+			changes = append(changes, keyChange{Key: k, Op: '+'})
+		case string(lv) != string(v):
+			changes = append(changes, keyChange{Key: k, Op: '~', Old: valueHash(lv), New: valueHash(v)})
+		}
+	}
+
+	for k := range live {
+		if _, ok := desired[k]; !ok {
+			changes = append(changes, keyChange{Key: k, Op: '-'})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// mergesSecret reports whether the secret of the object is written with the
+// merge strategy, which leaves the keys the controller doesn't manage alone.
+func mergesSecret(obj *manifest.Object) bool {
+	if obj.Project != nil {
+		return obj.Project.Spec.MergeStrategy()
+	}
+
+	return obj.Resource.Spec.MergeStrategy()
+}
+
+// valueHashKey is the key of the value hashes, generated for every run. A
+// plain hash of a short or guessable value could be reversed by hashing
+// candidates, so the hashes can only be compared within the same output.
+var valueHashKey = newValueHashKey()
+
+func newValueHashKey() []byte {
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("could not generate the value hash key: %s", err))
+	}
+
+	return key
+}
+
+// valueHash returns a short keyed hash of a secret value, to tell changed
+// values apart without revealing them.
+func valueHash(v []byte) string {
+	mac := hmac.New(sha256.New, valueHashKey)
+	mac.Write(v)
+	return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:6])
+}
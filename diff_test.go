@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	crdfake "github.com/manifoldco/kubernetes-credentials/client/clientset/versioned/fake"
+	"github.com/manifoldco/kubernetes-credentials/controller"
+	"github.com/manifoldco/kubernetes-credentials/filesource"
+	"github.com/manifoldco/kubernetes-credentials/manifest"
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+func TestDiffData(t *testing.T) {
+	live := map[string][]byte{
+		"SAME":    []byte("same"),
+		"CHANGED": []byte("old-secret"),
+		"REMOVED": []byte("removed"),
+	}
+	desired := map[string][]byte{
+		"SAME":    []byte("same"),
+		"CHANGED": []byte("new-secret"),
+		"ADDED":   []byte("added"),
+	}
+
+	changes := diffData(live, desired)
+
+	expected := []struct {
+		key string
+		op  byte
+	}{{"ADDED", '+'}, {"CHANGED", '~'}, {"REMOVED", '-'}}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %v", len(expected), changes)
+	}
+	for i, e := range expected {
+		if changes[i].Key != e.key || changes[i].Op != e.op {
+			t.Errorf("expected change %d to eq %c %s, got %c %s", i, e.op, e.key, changes[i].Op, changes[i].Key)
+		}
+	}
+
+	c := changes[1]
+	if c.Old == c.New {
+		t.Errorf("expected the hashes of changed values to differ, got %q", c.Old)
+	}
+	if c.Old != valueHash([]byte("old-secret")) || c.New != valueHash([]byte("new-secret")) {
+		t.Errorf("expected the hashes to be stable within a run, got %q and %q", c.Old, c.New)
+	}
+	for _, h := range []string{c.Old, c.New} {
+		if !strings.HasPrefix(h, "hmac:") || strings.Contains(h, "secret") {
+			t.Errorf("expected a hash which doesn't reveal the value, got %q", h)
+		}
+	}
+
+	if changes := diffData(live, live); len(changes) != 0 {
+		t.Errorf("expected no changes for the same data, got %v", changes)
+	}
+}
+
+func TestValueHash(t *testing.T) {
+	h := valueHash([]byte("password"))
+
+	defer func(key []byte) { valueHashKey = key }(valueHashKey)
+	valueHashKey = newValueHashKey()
+	if valueHash([]byte("password")) == h {
+		t.Error("expected the hash to depend on the key of the run")
+	}
+}
+
+func TestDiffObjects(t *testing.T) {
+	teams := map[string]filesource.Team{
+		"manifold": {
+			"production": {
+				"db": {"USERNAME": "prod-user", "PASSWORD": "prod-pass"},
+			},
+		},
+		"other": {
+			"production": {
+				"db": {"USERNAME": "other-user", "PASSWORD": "other-pass"},
+			},
+		},
+	}
+	src := filesource.New("manifold", teams)
+	cf := func(token, team string) (controller.CredentialSource, error) {
+		if token != "other-token" {
+			return nil, fmt.Errorf("invalid token '%s'", token)
+		}
+
+		return filesource.New(team, teams), nil
+	}
+
+	account := &primitives.ManifoldAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+		Spec:       &primitives.ManifoldAccountSpec{SecretName: "other-token"},
+	}
+	accountSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-token", Namespace: "default"},
+		Data: map[string][]byte{
+			primitives.DefaultAccountTokenKey: []byte("other-token"),
+			primitives.DefaultAccountTeamKey:  []byte("other"),
+		},
+	}
+
+	project := func(annotations map[string]string, strategy string) *manifest.Object {
+		return &manifest.Object{
+			File: "project.yml",
+			Line: 1,
+			Project: &primitives.Project{
+				ObjectMeta: metav1.ObjectMeta{Name: "creds", Annotations: annotations},
+				Spec: &primitives.ProjectSpec{
+					Name:          "production",
+					WriteStrategy: strategy,
+					Resources: []*primitives.ResourceSpec{
+						{Name: "db", Credentials: []*primitives.CredentialSpec{{Key: "USERNAME"}, {Key: "PASSWORD"}}},
+					},
+				},
+			},
+		}
+	}
+
+	withAccount := func(obj *manifest.Object, account string) *manifest.Object {
+		obj.Project.Spec.Account = account
+		return obj
+	}
+
+	secret := func(typ v1.SecretType, data map[string]string) *v1.Secret {
+		s := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "default"},
+			Data:       map[string][]byte{},
+			Type:       typ,
+		}
+		for k, v := range data {
+			s.Data[k] = []byte(v)
+		}
+		return s
+	}
+
+	tcs := []struct {
+		scenario string
+		object   *manifest.Object
+		secrets  []runtime.Object
+		code     int
+		output   []string
+	}{
+		{
+			scenario: "reports no changes",
+			object:   project(nil, ""),
+			secrets:  []runtime.Object{secret(v1.SecretTypeOpaque, map[string]string{"USERNAME": "prod-user", "PASSWORD": "prod-pass"})},
+			code:     diffExitSame,
+		},
+		{
+			scenario: "reports a secret which will be created",
+			object:   project(map[string]string{primitives.AnnotationPaused: "true"}, ""),
+			code:     diffExitChanged,
+			output:   []string{"Project 'creds' (secret default/creds), paused: will be created", "  + PASSWORD", "  + USERNAME"},
+		},
+		{
+			scenario: "reports changed keys and types",
+			object:   project(nil, ""),
+			secrets:  []runtime.Object{secret(v1.SecretTypeDockercfg, map[string]string{"USERNAME": "old-user", "OLD": "old"})},
+			code:     diffExitChanged,
+			output:   []string{"Project 'creds' (secret default/creds):", "  type: kubernetes.io/dockercfg -> Opaque", "  - OLD", "  + PASSWORD", "  ~ USERNAME hmac:"},
+		},
+		{
+			scenario: "ignores unmanaged keys when merging",
+			object:   project(nil, primitives.WriteStrategyMerge),
+			secrets:  []runtime.Object{secret(v1.SecretTypeOpaque, map[string]string{"USERNAME": "prod-user", "PASSWORD": "prod-pass", "OTHER": "kept"})},
+			code:     diffExitSame,
+		},
+		{
+			scenario: "loads credentials through the account of the object",
+			object:   withAccount(project(nil, ""), "other"),
+			secrets:  []runtime.Object{accountSecret, secret(v1.SecretTypeOpaque, map[string]string{"USERNAME": "other-user", "PASSWORD": "prod-pass"})},
+			code:     diffExitChanged,
+			output:   []string{"Project 'creds' (secret default/creds):", "  ~ PASSWORD hmac:"},
+		},
+		{
+			scenario: "fails for an account which doesn't exist",
+			object:   withAccount(project(nil, ""), "missing"),
+			secrets:  []runtime.Object{accountSecret},
+			code:     diffExitError,
+		},
+		{
+			scenario: "fails for credentials which can't be loaded",
+			object: &manifest.Object{
+				File:    "project.yml",
+				Line:    1,
+				Project: &primitives.Project{ObjectMeta: metav1.ObjectMeta{Name: "creds"}, Spec: &primitives.ProjectSpec{Name: "missing"}},
+			},
+			code: diffExitError,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			var buf bytes.Buffer
+			kc := fake.NewSimpleClientset(tc.secrets...)
+			crds := crdfake.NewSimpleClientset(account)
+
+			code := diffObjects(context.Background(), &buf, kc, clusterSources(kc, crds, src, cf), []*manifest.Object{tc.object}, "default")
+			if code != tc.code {
+				t.Errorf("expected the exit code to eq %d, got %d", tc.code, code)
+			}
+
+			out := buf.String()
+			for _, line := range tc.output {
+				if !strings.Contains(out, line) {
+					t.Errorf("expected the output to contain %q, got\n%s", line, out)
+				}
+			}
+			if len(tc.output) == 0 && out != "" {
+				t.Errorf("expected no output, got\n%s", out)
+			}
+			if strings.Contains(out, "prod-") || strings.Contains(out, "other-") || strings.Contains(out, "old-user") {
+				t.Errorf("expected the output not to reveal values, got\n%s", out)
+			}
+		})
+	}
+}
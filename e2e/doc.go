@@ -0,0 +1,8 @@
+// Package e2e runs the controller end to end against the stand-in Manifold API
+// of the manifoldtest package and fake Kubernetes clientsets, to verify its
+// retries, caching and credential rotation without network access.
+//
+// The tests are slow and timing based, so they're behind the e2e build tag:
+//
+//	go test -tags e2e ./e2e/...
+package e2e
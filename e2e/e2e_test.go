@@ -0,0 +1,383 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/manifoldco/go-manifold/integrations"
+
+	crdfake "github.com/manifoldco/kubernetes-credentials/client/clientset/versioned/fake"
+	"github.com/manifoldco/kubernetes-credentials/controller"
+	"github.com/manifoldco/kubernetes-credentials/filesource"
+	"github.com/manifoldco/kubernetes-credentials/manifoldtest"
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+	"github.com/manifoldco/kubernetes-credentials/snapshot"
+	"github.com/manifoldco/kubernetes-credentials/throttle"
+)
+
+// resync is short so the fake clientsets, which don't deliver watch events,
+// still get every object reconciled a couple of times per second.
+const resync = 100 * time.Millisecond
+
+const timeout = 10 * time.Second
+
+var fixtures = map[string]filesource.Team{
+	"manifold": {
+		"production": {
+			"db":    {"USERNAME": "prod-user", "PASSWORD": "prod-pass"},
+			"cache": {"URL": "redis://prod"},
+		},
+	},
+}
+
+// cluster is a controller running against the stand-in Manifold API and fake
+// clientsets.
+type cluster struct {
+	srv  *manifoldtest.Server
+	kc   *fake.Clientset
+	crds *crdfake.Clientset
+
+	cancel    context.CancelFunc
+	done      chan error
+	transport http.RoundTripper
+}
+
+// run starts the controller with the given options for a Project named creds
+// which loads the db resource of the production project. The controller is
+// stopped with stop.
+func run(t *testing.T, srv *manifoldtest.Server, opts controller.Options) *cluster {
+	t.Helper()
+
+	// go-manifold builds its HTTP clients on http.DefaultTransport, which is
+	// where the controller installs the throttle as well.
+	transport := http.DefaultTransport
+	if opts.Throttle != nil {
+		http.DefaultTransport = opts.Throttle.Transport(transport)
+	}
+
+	team := ""
+	source, err := integrations.NewClient(srv.Client("e2e-token"), &team)
+	if err != nil {
+		t.Fatalf("expected no error creating the Manifold client, got %q", err)
+	}
+	cf := func(token, team string) (controller.CredentialSource, error) {
+		cl, err := integrations.NewClient(srv.Client(token), &team)
+		if err != nil {
+			return nil, err
+		}
+
+		return cl, nil
+	}
+
+	project := &primitives.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "default", UID: "project-uid"},
+		Spec: &primitives.ProjectSpec{
+			Name: "production",
+			Resources: []*primitives.ResourceSpec{
+				{Name: "db", Credentials: []*primitives.CredentialSpec{{Key: "USERNAME"}, {Key: "PASSWORD"}}},
+			},
+		},
+	}
+
+	c := &cluster{
+		srv:  srv,
+		kc:   fake.NewSimpleClientset(),
+		crds: crdfake.NewSimpleClientset(project),
+		done: make(chan error, 1),
+
+		transport: transport,
+	}
+
+	opts.ResyncPeriod = resync
+	ctrl := controller.New(c.kc, c.crds, source, cf, opts)
+
+	var ctx context.Context
+	ctx, c.cancel = context.WithCancel(context.Background())
+	go func() { c.done <- ctrl.Run(ctx) }()
+
+	return c
+}
+
+// stop stops the controller and waits for it to finish.
+func (c *cluster) stop(t *testing.T) {
+	t.Helper()
+
+	c.cancel()
+	if err := <-c.done; err != nil {
+		t.Errorf("expected the controller to stop cleanly, got %q", err)
+	}
+	http.DefaultTransport = c.transport
+}
+
+// eventually polls the condition until it's true or the timeout expires.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// secretValue returns the value of a key of the secret, or an empty string
+// when the secret doesn't exist yet.
+func (c *cluster) secretValue(key string) string {
+	secret, err := c.kc.CoreV1().Secrets("default").Get("creds", metav1.GetOptions{})
+	if err != nil {
+		return ""
+	}
+
+	return string(secret.Data[key])
+}
+
+// condition returns the status of a condition of the Project, or an empty
+// string when it's not set.
+func (c *cluster) condition(t primitives.ConditionType) v1.ConditionStatus {
+	project, err := c.crds.ManifoldV1().Projects("default").Get("creds", metav1.GetOptions{})
+	if err != nil {
+		return ""
+	}
+
+	cond := project.Status.Condition(t)
+	if cond == nil {
+		return ""
+	}
+
+	return cond.Status
+}
+
+func (c *cluster) waitForSecret(t *testing.T, key, value string) {
+	t.Helper()
+
+	eventually(t, key+" to eq "+value, func() bool {
+		return c.secretValue(key) == value
+	})
+}
+
+func totalRequests(srv *manifoldtest.Server) int {
+	total := 0
+	for _, e := range []string{
+		manifoldtest.EndpointTeams,
+		manifoldtest.EndpointProjects,
+		manifoldtest.EndpointResources,
+		manifoldtest.EndpointCredentials,
+	} {
+		total += srv.Requests(e)
+	}
+
+	return total
+}
+
+// expectPaused checks that the controller made no requests for the given
+// pause after each rate limited response. Requests which were already sent
+// when the response arrived are ignored.
+func expectPaused(t *testing.T, srv *manifoldtest.Server, pause time.Duration) {
+	t.Helper()
+
+	log := srv.Log()
+	limited := 0
+	for _, req := range log {
+		if req.Status != http.StatusTooManyRequests {
+			continue
+		}
+		limited++
+
+		for _, next := range log {
+			if gap := next.Received.Sub(req.Responded); gap > 0 && gap < pause {
+				t.Errorf("expected no requests for %s after a rate limited response, got one after %s", pause, gap)
+			}
+		}
+	}
+
+	if limited == 0 {
+		t.Error("expected rate limited responses")
+	}
+}
+
+func TestSync(t *testing.T) {
+	srv := manifoldtest.NewServer("manifold", fixtures)
+	defer srv.Close()
+
+	c := run(t, srv, controller.Options{})
+	defer c.stop(t)
+
+	c.waitForSecret(t, "USERNAME", "prod-user")
+	c.waitForSecret(t, "PASSWORD", "prod-pass")
+	eventually(t, "the Project to be ready", func() bool {
+		return c.condition(primitives.ConditionReady) == v1.ConditionTrue
+	})
+}
+
+func TestRotation(t *testing.T) {
+	srv := manifoldtest.NewServer("manifold", fixtures)
+	defer srv.Close()
+
+	c := run(t, srv, controller.Options{})
+	defer c.stop(t)
+
+	c.waitForSecret(t, "PASSWORD", "prod-pass")
+
+	srv.SetCredentials("manifold", "production", "db", filesource.Credentials{
+		"USERNAME": "prod-user",
+		"PASSWORD": "rotated-pass",
+	})
+
+	c.waitForSecret(t, "PASSWORD", "rotated-pass")
+}
+
+func TestRetries(t *testing.T) {
+	tcs := []struct {
+		scenario string
+		inject   func(*manifoldtest.Server)
+		opts     controller.Options
+		pause    time.Duration
+	}{
+		{
+			scenario: "server errors",
+			inject: func(srv *manifoldtest.Server) {
+				srv.FailNext(3, http.StatusServiceUnavailable)
+			},
+			opts: controller.Options{
+				Throttle: &throttle.Throttle{
+					Limiter: throttle.NewLimiter(100, 10),
+					Breaker: throttle.NewBreaker(5, resync),
+				},
+			},
+		},
+		{
+			scenario: "rate limits",
+			inject: func(srv *manifoldtest.Server) {
+				srv.RateLimitNext(2, 0)
+			},
+			opts: controller.Options{
+				Throttle: &throttle.Throttle{
+					Limiter:    throttle.NewLimiter(100, 10),
+					RetryAfter: 200 * time.Millisecond,
+				},
+			},
+			pause: 200 * time.Millisecond,
+		},
+		{
+			scenario: "rate limits with a Retry-After",
+			inject: func(srv *manifoldtest.Server) {
+				srv.RateLimitNext(1, time.Second)
+			},
+			opts: controller.Options{
+				Throttle: &throttle.Throttle{
+					Limiter:    throttle.NewLimiter(100, 10),
+					RetryAfter: 200 * time.Millisecond,
+				},
+			},
+			pause: time.Second,
+		},
+		{
+			scenario: "slow responses",
+			inject: func(srv *manifoldtest.Server) {
+				srv.SetLatency(300 * time.Millisecond)
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			srv := manifoldtest.NewServer("manifold", fixtures)
+			defer srv.Close()
+
+			tc.inject(srv)
+			c := run(t, srv, tc.opts)
+			defer c.stop(t)
+
+			c.waitForSecret(t, "USERNAME", "prod-user")
+			if n := totalRequests(srv); n < 2 {
+				t.Errorf("expected the controller to retry, got %d requests", n)
+			}
+			if tc.pause > 0 {
+				expectPaused(t, srv, tc.pause)
+			}
+		})
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	tcs := []struct {
+		scenario string
+		inject   func(srv *manifoldtest.Server)
+	}{
+		{
+			scenario: "server errors",
+			inject: func(srv *manifoldtest.Server) {
+				srv.FailNext(1000000, http.StatusInternalServerError)
+			},
+		},
+		{
+			// A proxy in front of the API answers with an HTML error page,
+			// which go-manifold can't decode.
+			scenario: "proxy error pages",
+			inject: func(srv *manifoldtest.Server) {
+				srv.FailNextHTML(1000000, http.StatusBadGateway)
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			srv := manifoldtest.NewServer("manifold", fixtures)
+			defer srv.Close()
+
+			dir, err := ioutil.TempDir("", "e2e-snapshot")
+			if err != nil {
+				t.Fatalf("expected no error, got %q", err)
+			}
+			defer os.RemoveAll(dir)
+
+			snap, err := snapshot.New(&snapshot.FileStore{Path: filepath.Join(dir, "snapshot")}, make([]byte, snapshot.KeySize), time.Hour)
+			if err != nil {
+				t.Fatalf("expected no error, got %q", err)
+			}
+
+			// The controller always installs the throttle, which is where
+			// failed responses without a JSON body are classified.
+			c := run(t, srv, controller.Options{Snapshot: snap, Throttle: &throttle.Throttle{}})
+			defer c.stop(t)
+
+			c.waitForSecret(t, "PASSWORD", "prod-pass")
+
+			// During an outage the secret keeps the last known good
+			// credentials, even when they were rotated in the meantime.
+			srv.SetCredentials("manifold", "production", "db", filesource.Credentials{
+				"USERNAME": "prod-user",
+				"PASSWORD": "rotated-pass",
+			})
+			tc.inject(srv)
+
+			eventually(t, "the Project to be served from the snapshot", func() bool {
+				return c.condition(primitives.ConditionServedFromCache) == v1.ConditionTrue
+			})
+			if got := c.secretValue("PASSWORD"); got != "prod-pass" {
+				t.Errorf("expected PASSWORD to eq %q, got %q", "prod-pass", got)
+			}
+
+			srv.ClearFaults()
+
+			c.waitForSecret(t, "PASSWORD", "rotated-pass")
+			eventually(t, "the snapshot condition to be removed", func() bool {
+				return c.condition(primitives.ConditionServedFromCache) == ""
+			})
+		})
+	}
+}
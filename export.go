@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/manifoldco/kubernetes-credentials/client/clientset/versioned"
+)
+
+// exportFormats are the output formats of the export subcommand.
+var exportFormats = map[string]func(w io.Writer, values map[string][]byte) error{
+	"dotenv": writeDotenv,
+	"shell":  writeShell,
+	"json":   writeJSON,
+	"docker": writeDockerEnv,
+}
+
+// envKeyRegexp matches the keys which can be used as environment variable
+// names.
+var envKeyRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// runExport prints the credentials of Projects and Resources, from manifests
+// or from the cluster, as environment variables for local development.
+func runExport(args []string) int {
+	fs := newFlagSet("export", "<file, directory, project/<name> or resource/<name>>...", "Prints the credentials of the Projects and Resources in the given manifests, or in the cluster, as environment variables.\nManifoldAccounts aren't resolved: objects which reference one are exported with the default credential source.")
+	format := fs.String("format", "dotenv", "The output format: dotenv, shell, json or docker.")
+	credentialsFile := fs.String("credentials-file", "", "Load credentials from this local file or directory instead of the Manifold API.")
+	o := &options{}
+	registerClusterFlags(fs, o)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	write, ok := exportFormats[*format]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown format '%s', use dotenv, shell, json or docker\n", *format)
+		return 2
+	}
+
+	setupCommandLogging()
+	ctx := context.Background()
+
+	objects, err := loadObjects(fs.Args(), func() (versioned.Interface, string, error) {
+		_, crds, namespace, err := clusterClients(o)
+		return crds, namespace, err
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	src, err := commandSource(ctx, *credentialsFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	code := 0
+	values := map[string][]byte{}
+	from := map[string]string{}
+	for _, obj := range objects {
+		vs, err := desiredValues(ctx, defaultSources(src), obj)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s:%d: could not export %s '%s': %s\n", obj.File, obj.Line, obj.Kind(), obj.Meta().Name, err)
+			code = 1
+			continue
+		}
+
+		name := fmt.Sprintf("%s '%s'", obj.Kind(), obj.Meta().Name)
+		for k, v := range vs {
+			if prev, ok := from[k]; ok {
+				log.WithField("key", k).Warnf("%s overrides the value of %s", name, prev)
+			}
+			values[k] = v
+			from[k] = name
+		}
+	}
+
+	if err := write(os.Stdout, values); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return code
+}
+
+// sortedKeys returns the keys of the values in order.
+func sortedKeys(values map[string][]byte) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// envKeys returns the keys of the values which can be used as environment
+// variable names, in order. Other keys are skipped with a warning.
+func envKeys(values map[string][]byte) []string {
+	var keys []string
+	for _, k := range sortedKeys(values) {
+		if !envKeyRegexp.MatchString(k) {
+			log.WithField("key", k).Warn("Skipping key which isn't a valid environment variable name")
+			continue
+		}
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// writeDotenv writes the values as a .env file, with double quoted values so
+// newlines can be escaped. Dollar signs are escaped too, as dotenv loaders
+// expand variables in double quoted values.
+func writeDotenv(w io.Writer, values map[string][]byte) error {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`, "\r", `\r`)
+
+	var buf bytes.Buffer
+	for _, k := range envKeys(values) {
+		fmt.Fprintf(&buf, "%s=\"%s\"\n", k, r.Replace(string(values[k])))
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// writeShell writes the values as export statements which can be evaluated by
+// a POSIX shell.
+func writeShell(w io.Writer, values map[string][]byte) error {
+	r := strings.NewReplacer(`'`, `'"'"'`)
+
+	var buf bytes.Buffer
+	for _, k := range envKeys(values) {
+		fmt.Fprintf(&buf, "export %s='%s'\n", k, r.Replace(string(values[k])))
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// writeJSON writes the values as a JSON object.
+func writeJSON(w io.Writer, values map[string][]byte) error {
+	out := make(map[string]string, len(values))
+	for k, v := range values {
+		out[k] = string(v)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// writeDockerEnv writes the values in the format of docker's --env-file flag.
+// Docker doesn't support quoting, so values can't contain newlines.
+func writeDockerEnv(w io.Writer, values map[string][]byte) error {
+	var buf bytes.Buffer
+	for _, k := range envKeys(values) {
+		if bytes.ContainsAny(values[k], "\r\n") {
+			return fmt.Errorf("the value of '%s' contains a newline, which docker env files don't support", k)
+		}
+		fmt.Fprintf(&buf, "%s=%s\n", k, values[k])
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
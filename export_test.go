@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+var exportValues = map[string][]byte{
+	"PLAIN":     []byte("value"),
+	"QUOTES":    []byte(`it's "quoted"`),
+	"NEWLINES":  []byte("line one\nline two\r\n"),
+	"DOLLAR":    []byte("pa$$word $HOME ${USER}"),
+	"BACKSLASH": []byte(`C:\path\n`),
+	"not-env":   []byte("skipped"),
+}
+
+func TestWriteDotenv(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeDotenv(&buf, exportValues); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	expected := `BACKSLASH="C:\\path\\n"
+DOLLAR="pa\$\$word \$HOME \${USER}"
+NEWLINES="line one\nline two\r\n"
+PLAIN="value"
+QUOTES="it's \"quoted\""
+`
+	if got := buf.String(); got != expected {
+		t.Errorf("expected output to eq\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestWriteShell(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeShell(&buf, exportValues); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	expected := `export BACKSLASH='C:\path\n'
+export DOLLAR='pa$$word $HOME ${USER}'
+export NEWLINES='line one
+line two` + "\r" + `
+'
+export PLAIN='value'
+export QUOTES='it'"'"'s "quoted"'
+`
+	if got := buf.String(); got != expected {
+		t.Errorf("expected output to eq\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, exportValues); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	var got map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("expected valid JSON, got %q", err)
+	}
+
+	if len(got) != len(exportValues) {
+		t.Errorf("expected %d keys, got %d", len(exportValues), len(got))
+	}
+	for k, v := range exportValues {
+		if got[k] != string(v) {
+			t.Errorf("expected %s to eq %q, got %q", k, v, got[k])
+		}
+	}
+}
+
+func TestWriteDockerEnv(t *testing.T) {
+	t.Run("writes values as is", func(t *testing.T) {
+		var buf bytes.Buffer
+		values := map[string][]byte{"DOLLAR": []byte("pa$$word"), "QUOTES": []byte(`"quoted"`)}
+		if err := writeDockerEnv(&buf, values); err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+
+		expected := "DOLLAR=pa$$word\nQUOTES=\"quoted\"\n"
+		if got := buf.String(); got != expected {
+			t.Errorf("expected output to eq %q, got %q", expected, got)
+		}
+	})
+
+	t.Run("fails for newlines", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeDockerEnv(&buf, exportValues); err == nil {
+			t.Error("expected an error for a value with a newline")
+		}
+		if buf.Len() != 0 {
+			t.Errorf("expected nothing to be written, got %q", buf.String())
+		}
+	})
+}
@@ -0,0 +1,18 @@
+// Package filesource provides a credential source which loads credentials from
+// a local file or directory instead of the Manifold API. It's meant for
+// air-gapped development clusters and deterministic tests.
+//
+// A file is YAML or JSON and nests the credentials by team, project and
+// resource label:
+//
+//	teams:
+//	  manifold:
+//	    manifold-terraform:
+//	      custom-resource1:
+//	        TOKEN_ID: my-token-id
+//
+// A directory mirrors the same structure, with a file per credential key which
+// holds the value:
+//
+//	<dir>/manifold/manifold-terraform/custom-resource1/TOKEN_ID
+package filesource
@@ -0,0 +1,285 @@
+package filesource
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/manifoldco/go-manifold/integrations/primitives"
+)
+
+// Credentials are the credential values of a resource, keyed by credential
+// key.
+type Credentials map[string]string
+
+// Project holds the resources of a project, keyed by resource label.
+type Project map[string]Credentials
+
+// Team holds the projects of a team, keyed by project label.
+type Team map[string]Project
+
+// File is the structure of a credentials file.
+type File struct {
+	Teams map[string]Team `json:"teams"`
+}
+
+// Source is a credential source backed by a local file or directory. Resources
+// without a team are looked up in the source's default team.
+type Source struct {
+	team  string
+	teams map[string]Team
+}
+
+// New returns a Source for the given teams with the given default team.
+func New(team string, teams map[string]Team) *Source {
+	return &Source{team: team, teams: teams}
+}
+
+// Load returns a Source for the file or directory at the given path.
+func Load(path, team string) (*Source, error) {
+	teams, err := LoadTeams(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(team, teams), nil
+}
+
+// LoadTeams reads the teams from the file or directory at the given path.
+func LoadTeams(path string) (map[string]Team, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if fi.IsDir() {
+		return loadDir(path)
+	}
+
+	bts, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f File
+	if err := yaml.Unmarshal(bts, &f); err != nil {
+		return nil, fmt.Errorf("could not parse '%s': %s", path, err)
+	}
+
+	return f.Teams, nil
+}
+
+// loadDir reads a <team>/<project>/<resource>/<key> directory structure.
+// Symlinks are followed, as a mounted Secret volume links every entry into a
+// hidden directory which is swapped on updates.
+func loadDir(root string) (map[string]Team, error) {
+	teams := map[string]Team{}
+
+	err := walkDir(root, nil, func(parts []string, path string) error {
+		bts, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		team, project, resource, key := parts[0], parts[1], parts[2], parts[3]
+		if teams[team] == nil {
+			teams[team] = Team{}
+		}
+		if teams[team][project] == nil {
+			teams[team][project] = Project{}
+		}
+		if teams[team][project][resource] == nil {
+			teams[team][project][resource] = Credentials{}
+		}
+
+		teams[team][project][resource][key] = string(bts)
+		return nil
+	})
+
+	return teams, err
+}
+
+// walkDir calls fn for every file four levels below dir, with the names of the
+// directories leading up to it. Unlike filepath.Walk, symlinks are resolved.
+// Hidden entries, like the ..data link of a Secret volume, are skipped.
+func walkDir(dir string, parts []string, fn func(parts []string, path string) error) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		fi, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		names := append(parts[:len(parts):len(parts)], entry.Name())
+		switch {
+		case fi.IsDir() && len(names) < 4:
+			err = walkDir(path, names, fn)
+		case !fi.IsDir() && len(names) == 4:
+			err = fn(names, path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetResourcesCredentialValues returns the credential values for the given
+// resources within a project, keyed by resource label. When no resources are
+// given, the values of all resources in the project are returned.
+func (s *Source) GetResourcesCredentialValues(ctx context.Context, project *string, resources []*primitives.Resource) (map[string][]*primitives.CredentialValue, error) {
+	if project == nil {
+		return nil, fmt.Errorf("a project is required")
+	}
+
+	if len(resources) == 0 {
+		p, err := s.project(s.team, *project)
+		if err != nil {
+			return nil, err
+		}
+
+		for label := range p {
+			resources = append(resources, &primitives.Resource{Name: label})
+		}
+	}
+
+	values := make(map[string][]*primitives.CredentialValue, len(resources))
+	for _, r := range resources {
+		cvs, err := s.GetResourceCredentialValues(ctx, project, r)
+		if err != nil {
+			return nil, err
+		}
+
+		values[r.Name] = cvs
+	}
+
+	return values, nil
+}
+
+// GetResourceCredentialValues returns the credential values for a single
+// resource. When no project is given, the resource is looked up in all
+// projects of the team and it's an error if more than one project has a
+// resource with that label.
+func (s *Source) GetResourceCredentialValues(ctx context.Context, project *string, resource *primitives.Resource) ([]*primitives.CredentialValue, error) {
+	team := resource.Team
+	if team == "" {
+		team = s.team
+	}
+
+	creds, err := s.resource(team, project, resource.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return credentialValues(resource, creds)
+}
+
+func (s *Source) project(team, label string) (Project, error) {
+	t, ok := s.teams[team]
+	if !ok {
+		return nil, fmt.Errorf("team '%s' not found", team)
+	}
+
+	p, ok := t[label]
+	if !ok {
+		return nil, fmt.Errorf("project '%s' not found", label)
+	}
+
+	return p, nil
+}
+
+func (s *Source) resource(team string, project *string, label string) (Credentials, error) {
+	if project != nil {
+		p, err := s.project(team, *project)
+		if err != nil {
+			return nil, err
+		}
+
+		creds, ok := p[label]
+		if !ok {
+			return nil, fmt.Errorf("resource '%s' not found in project '%s'", label, *project)
+		}
+
+		return creds, nil
+	}
+
+	t, ok := s.teams[team]
+	if !ok {
+		return nil, fmt.Errorf("team '%s' not found", team)
+	}
+
+	var found []string
+	var creds Credentials
+	for pl, p := range t {
+		if c, ok := p[label]; ok {
+			found = append(found, pl)
+			creds = c
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("resource '%s' not found", label)
+	case 1:
+		return creds, nil
+	default:
+		sort.Strings(found)
+		return nil, fmt.Errorf("resource '%s' is ambiguous, it exists in projects %s", label, strings.Join(found, ", "))
+	}
+}
+
+// credentialValues filters the credentials of a resource down to the ones
+// that were requested, applying aliases and defaults. Without a list of
+// credentials, all of them are returned.
+func credentialValues(resource *primitives.Resource, creds Credentials) ([]*primitives.CredentialValue, error) {
+	if len(resource.Credentials) == 0 {
+		keys := make([]string, 0, len(creds))
+		for k := range creds {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		values := make([]*primitives.CredentialValue, len(keys))
+		for i, k := range keys {
+			values[i] = &primitives.CredentialValue{
+				Credential: primitives.Credential{Key: k},
+				Value:      creds[k],
+			}
+		}
+
+		return values, nil
+	}
+
+	values := make([]*primitives.CredentialValue, len(resource.Credentials))
+	for i, c := range resource.Credentials {
+		v, ok := creds[c.Key]
+		if !ok {
+			if c.Default == "" {
+				return nil, fmt.Errorf("credential '%s' not found for resource '%s'", c.Key, resource.Name)
+			}
+			v = c.Default
+		}
+
+		values[i] = &primitives.CredentialValue{
+			Credential: *c,
+			Value:      v,
+		}
+	}
+
+	return values, nil
+}
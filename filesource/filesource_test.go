@@ -0,0 +1,179 @@
+package filesource
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/manifoldco/go-manifold/integrations/primitives"
+)
+
+func testSource() *Source {
+	return New("manifold", map[string]Team{
+		"manifold": {
+			"production": {
+				"db":    {"USERNAME": "prod-user", "PASSWORD": "prod-pass"},
+				"cache": {"URL": "redis://prod"},
+			},
+			"staging": {
+				"db": {"USERNAME": "staging-user", "PASSWORD": "staging-pass"},
+			},
+		},
+	})
+}
+
+func ptr(s string) *string { return &s }
+
+func TestSource_GetResourceCredentialValues(t *testing.T) {
+	ctx := context.Background()
+	src := testSource()
+
+	t.Run("with a project", func(t *testing.T) {
+		cvs, err := src.GetResourceCredentialValues(ctx, ptr("staging"), &primitives.Resource{Name: "db"})
+		if err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+		if len(cvs) != 2 {
+			t.Fatalf("expected 2 credentials, got %d", len(cvs))
+		}
+		if cvs[0].Key != "PASSWORD" || cvs[0].Value != "staging-pass" {
+			t.Errorf("expected PASSWORD to eq %q, got %q", "staging-pass", cvs[0].Value)
+		}
+	})
+
+	t.Run("with an unambiguous resource", func(t *testing.T) {
+		cvs, err := src.GetResourceCredentialValues(ctx, nil, &primitives.Resource{Name: "cache"})
+		if err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+		if len(cvs) != 1 || cvs[0].Value != "redis://prod" {
+			t.Fatalf("expected the production cache, got %v", cvs)
+		}
+	})
+
+	t.Run("with an ambiguous resource", func(t *testing.T) {
+		_, err := src.GetResourceCredentialValues(ctx, nil, &primitives.Resource{Name: "db"})
+		if err == nil || !strings.Contains(err.Error(), "ambiguous") {
+			t.Fatalf("expected an ambiguity error, got %v", err)
+		}
+	})
+
+	t.Run("with aliases and defaults", func(t *testing.T) {
+		cvs, err := src.GetResourceCredentialValues(ctx, ptr("production"), &primitives.Resource{
+			Name: "db",
+			Credentials: []*primitives.Credential{
+				{Key: "USERNAME", Name: "DB_USER"},
+				{Key: "PORT", Default: "5432"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+		if cvs[0].Name != "DB_USER" || cvs[0].Value != "prod-user" {
+			t.Errorf("expected DB_USER to eq %q, got %q", "prod-user", cvs[0].Value)
+		}
+		if cvs[1].Value != "5432" {
+			t.Errorf("expected PORT to default to %q, got %q", "5432", cvs[1].Value)
+		}
+	})
+
+	t.Run("with a missing credential", func(t *testing.T) {
+		_, err := src.GetResourceCredentialValues(ctx, ptr("production"), &primitives.Resource{
+			Name:        "db",
+			Credentials: []*primitives.Credential{{Key: "PORT"}},
+		})
+		if err == nil {
+			t.Fatal("expected an error, got none")
+		}
+	})
+}
+
+func TestSource_GetResourcesCredentialValues(t *testing.T) {
+	values, err := testSource().GetResourcesCredentialValues(context.Background(), ptr("production"), nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if len(values) != 2 {
+		t.Fatalf("expected all resources of the project, got %d", len(values))
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filesource")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Run("with a file", func(t *testing.T) {
+		path := filepath.Join(dir, "credentials.yml")
+		contents := "teams:\n  manifold:\n    production:\n      db:\n        USERNAME: prod-user\n"
+		if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+
+		src, err := Load(path, "manifold")
+		if err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+
+		if v := src.teams["manifold"]["production"]["db"]["USERNAME"]; v != "prod-user" {
+			t.Fatalf("expected USERNAME to eq %q, got %q", "prod-user", v)
+		}
+	})
+
+	t.Run("with a directory", func(t *testing.T) {
+		root := filepath.Join(dir, "tree")
+		resource := filepath.Join(root, "manifold", "production", "db")
+		if err := os.MkdirAll(resource, 0700); err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(resource, "USERNAME"), []byte("prod-user"), 0600); err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+
+		src, err := Load(root, "manifold")
+		if err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+
+		if v := src.teams["manifold"]["production"]["db"]["USERNAME"]; v != "prod-user" {
+			t.Fatalf("expected USERNAME to eq %q, got %q", "prod-user", v)
+		}
+	})
+
+	t.Run("with a mounted secret volume", func(t *testing.T) {
+		// Secret volumes link each entry into a hidden ..data directory,
+		// which links to the current timestamped copy of the data.
+		root := filepath.Join(dir, "volume")
+		resource := filepath.Join(root, "..2018_01_01", "manifold", "production", "db")
+		if err := os.MkdirAll(resource, 0700); err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(resource, "USERNAME"), []byte("prod-user"), 0600); err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+		if err := os.Symlink("..2018_01_01", filepath.Join(root, "..data")); err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+		if err := os.Symlink(filepath.Join("..data", "manifold"), filepath.Join(root, "manifold")); err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+
+		src, err := Load(root, "manifold")
+		if err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+
+		if len(src.teams) != 1 {
+			t.Errorf("expected only the linked team to be loaded, got %v", src.teams)
+		}
+		if v := src.teams["manifold"]["production"]["db"]["USERNAME"]; v != "prod-user" {
+			t.Fatalf("expected USERNAME to eq %q, got %q", "prod-user", v)
+		}
+	})
+}
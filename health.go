@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/manifoldco/kubernetes-credentials/controller"
+)
+
+// healthServer serves the liveness and readiness probes of the controller.
+// Until the CRDs are established and the controller is set, the process is
+// considered alive but not ready.
+type healthServer struct {
+	maxAPIFailing time.Duration
+
+	mu   sync.RWMutex
+	ctrl *controller.Controller
+}
+
+func (h *healthServer) setController(ctrl *controller.Controller) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.ctrl = ctrl
+}
+
+func (h *healthServer) controller() *controller.Controller {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.ctrl
+}
+
+func (h *healthServer) healthz(w http.ResponseWriter, r *http.Request) {
+	ctrl := h.controller()
+	if ctrl == nil {
+		writeProbe(w, nil)
+		return
+	}
+
+	writeProbe(w, ctrl.Healthy())
+}
+
+func (h *healthServer) readyz(w http.ResponseWriter, r *http.Request) {
+	ctrl := h.controller()
+	if ctrl == nil {
+		writeProbe(w, errors.New("CRDs have not been established yet"))
+		return
+	}
+
+	writeProbe(w, ctrl.Ready(h.maxAPIFailing))
+}
+
+// serve serves the probes on the given address.
+func (h *healthServer) serve(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", h.healthz)
+	mux.HandleFunc("/readyz", h.readyz)
+
+	log.WithField("addr", addr).Info("Serving health checks")
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.WithError(err).Error("issue serving health checks")
+	}
+}
+
+func writeProbe(w http.ResponseWriter, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Write([]byte("ok"))
+}
@@ -0,0 +1,4 @@
+// Package logging configures the controller's log output. It makes sure
+// credential values can't end up in the logs by redacting them from every
+// entry before it's formatted.
+package logging
@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// The supported log formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// ReconcileIDField is the field which correlates all log lines of a single
+// reconcile.
+const ReconcileIDField = "reconcile_id"
+
+// Setup configures the global logger with the given format and level. The
+// formatter is always wrapped in a RedactingFormatter.
+func Setup(format, level string) error {
+	lvl, err := log.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	var formatter log.Formatter
+	switch format {
+	case FormatText:
+		formatter = &log.TextFormatter{}
+	case FormatJSON:
+		formatter = &log.JSONFormatter{}
+	default:
+		return fmt.Errorf("Log format '%s' not supported", format)
+	}
+
+	log.SetLevel(lvl)
+	log.SetFormatter(&RedactingFormatter{Formatter: formatter})
+	return nil
+}
+
+// NewReconcileID returns a random ID to correlate the log lines of a
+// reconcile.
+func NewReconcileID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,117 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Redacted is what redacted values are replaced with.
+const Redacted = "[REDACTED]"
+
+// minSecretLength is the minimum length of a value to be redacted from log
+// messages. Shorter values would redact unrelated text all over the place.
+// Byte slices and maps are always redacted, regardless of their contents.
+const minSecretLength = 3
+
+var secrets = &registry{values: map[string]int{}}
+
+// registry keeps a reference count of the secret values that are currently in
+// use so they can be scrubbed from the logs.
+type registry struct {
+	mu     sync.RWMutex
+	values map[string]int
+}
+
+// Redact registers the given values as secrets which will be scrubbed from all
+// log output until the returned function is called.
+func Redact(values ...string) func() {
+	var registered []string
+
+	secrets.mu.Lock()
+	for _, v := range values {
+		if len(v) < minSecretLength {
+			continue
+		}
+		secrets.values[v]++
+		registered = append(registered, v)
+	}
+	secrets.mu.Unlock()
+
+	return func() {
+		secrets.mu.Lock()
+		defer secrets.mu.Unlock()
+
+		for _, v := range registered {
+			secrets.values[v]--
+			if secrets.values[v] <= 0 {
+				delete(secrets.values, v)
+			}
+		}
+	}
+}
+
+// RedactBytes is like Redact but takes a map of raw values, like the data of a
+// secret.
+func RedactBytes(data map[string][]byte) func() {
+	values := make([]string, 0, len(data))
+	for _, v := range data {
+		values = append(values, string(v))
+	}
+
+	return Redact(values...)
+}
+
+// scrub replaces all registered secrets in the given string.
+func scrub(s string) string {
+	secrets.mu.RLock()
+	defer secrets.mu.RUnlock()
+
+	for v := range secrets.values {
+		if strings.Contains(s, v) {
+			s = strings.Replace(s, v, Redacted, -1)
+		}
+	}
+
+	return s
+}
+
+// RedactingFormatter wraps another formatter and redacts credential values
+// before the entry is formatted. Registered secrets are scrubbed from the
+// message and every field, and raw bytes and maps are never logged.
+type RedactingFormatter struct {
+	Formatter log.Formatter
+}
+
+// Format implements the logrus.Formatter interface.
+func (f *RedactingFormatter) Format(entry *log.Entry) ([]byte, error) {
+	redacted := *entry
+	redacted.Message = scrub(entry.Message)
+	redacted.Data = make(log.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		redacted.Data[k] = redactValue(v)
+	}
+
+	return f.Formatter.Format(&redacted)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case []byte, map[string][]byte, map[string]string:
+		return Redacted
+	case string:
+		return scrub(t)
+	case error:
+		return scrub(t.Error())
+	case fmt.Stringer:
+		return scrub(t.String())
+	case bool, int, int32, int64, uint, uint32, uint64, float32, float64:
+		return t
+	default:
+		return scrub(fmt.Sprintf("%v", t))
+	}
+}
@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestRedactingFormatter(t *testing.T) {
+	formatter := &RedactingFormatter{Formatter: &log.JSONFormatter{}}
+	format := func(entry *log.Entry) string {
+		out, err := formatter.Format(entry)
+		if err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+		return string(out)
+	}
+
+	t.Run("with registered secrets", func(t *testing.T) {
+		release := Redact("my-secret-token")
+		defer release()
+
+		entry := log.WithFields(log.Fields{
+			"key":   "TOKEN_ID",
+			"value": "the value is my-secret-token",
+		}).WithError(errors.New("could not decode my-secret-token"))
+		entry.Message = "got my-secret-token"
+
+		out := format(entry)
+		if strings.Contains(out, "my-secret-token") {
+			t.Fatalf("expected secret to be redacted, got %s", out)
+		}
+		if !strings.Contains(out, "TOKEN_ID") {
+			t.Fatalf("expected key to be logged, got %s", out)
+		}
+	})
+
+	t.Run("with released secrets", func(t *testing.T) {
+		Redact("released-value")()
+
+		entry := log.WithField("value", "released-value")
+		if out := format(entry); !strings.Contains(out, "released-value") {
+			t.Fatalf("expected released value to be logged, got %s", out)
+		}
+	})
+
+	t.Run("with raw data", func(t *testing.T) {
+		entry := log.WithFields(log.Fields{
+			"bytes": []byte("raw-bytes"),
+			"data":  map[string][]byte{"KEY": []byte("raw-data")},
+		})
+
+		out := format(entry)
+		if strings.Contains(out, "raw-bytes") || strings.Contains(out, "raw-data") {
+			t.Fatalf("expected raw data to be redacted, got %s", out)
+		}
+	})
+}
@@ -2,35 +2,64 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"flag"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/serializer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 
-	"github.com/manifoldco/go-manifold"
 	"github.com/manifoldco/go-manifold/integrations"
 
+	"github.com/manifoldco/kubernetes-credentials/client/clientset/versioned"
 	"github.com/manifoldco/kubernetes-credentials/controller"
 	"github.com/manifoldco/kubernetes-credentials/crd"
-	"github.com/manifoldco/kubernetes-credentials/crd/projects"
-	"github.com/manifoldco/kubernetes-credentials/crd/resources"
 	"github.com/manifoldco/kubernetes-credentials/primitives"
+	"github.com/manifoldco/kubernetes-credentials/webhook"
 )
 
+// snapshotFlushInterval is how often the credentials snapshot is written.
+const snapshotFlushInterval = time.Minute
+
 func main() {
+	runCommand(os.Args[1:])
+
+	var opts options
+	opts.register(flag.CommandLine)
+	flag.Parse()
+
+	if err := opts.setupLogging(); err != nil {
+		log.Fatal(err)
+	}
+
+	adoption, err := controller.ParseAdoptionPolicy(opts.adoptionPolicy)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := primitives.ValidateDeletionPolicy(opts.deletionPolicy); err != nil {
+		log.Fatal(err)
+	}
+	if opts.tokenReloadInterval <= 0 {
+		log.Fatal("--token-reload-interval must be positive")
+	}
+
 	log.Info("Starting the controller...")
 
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	defer cancelFunc()
 
-	cfg, err := rest.InClusterConfig()
+	health := &healthServer{maxAPIFailing: opts.maxAPIFailing}
+	if opts.healthAddr != "" {
+		go health.serve(opts.healthAddr)
+	}
+
+	cfg, err := opts.restConfig()
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -51,54 +80,131 @@ func main() {
 	if err := crd.CreateCRD(cs, primitives.CRDResourcesName, primitives.CRDResourcesPlural, primitives.CRDGroup, primitives.CRDVersion); err != nil {
 		log.Fatal(err)
 	}
+	if err := crd.CreateCRD(cs, primitives.CRDAccountsName, primitives.CRDAccountsPlural, primitives.CRDGroup, primitives.CRDVersion); err != nil {
+		log.Fatal(err)
+	}
+	if err := crd.CreateClusterCRD(cs, primitives.CRDPoliciesName, primitives.CRDPoliciesPlural, primitives.CRDGroup, primitives.CRDVersion); err != nil {
+		log.Fatal(err)
+	}
 
-	rc, err := newClient(cfg)
+	crds, err := versioned.NewForConfig(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	ptr := func(str string) *string {
-		return &str
+	// go-manifold builds its HTTP clients on http.DefaultTransport and has no
+	// option to replace it, so that's where the throttle goes. The Kubernetes
+	// clients above keep the transport they were built with.
+	thr := opts.throttle()
+	http.DefaultTransport = thr.Transport(http.DefaultTransport)
+
+	// The default source is optional. Without it, every Project and Resource
+	// needs to reference a ManifoldAccount in its own namespace.
+	source, team, reloader, err := defaultSource(ctx, &opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if source == nil {
+		log.Info("No MANIFOLD_API_TOKEN set, only ManifoldAccounts will be used")
 	}
 
-	manifoldClient := manifold.New(
-		manifold.WithAPIToken(os.Getenv("MANIFOLD_API_TOKEN")),
-		manifold.WithUserAgent(fmt.Sprintf("kubernetes-%s", Version)),
-	)
-	wrapper, err := integrations.NewClient(manifoldClient, ptr(os.Getenv("MANIFOLD_TEAM")))
+	snap, err := newSnapshot(&opts, kc)
 	if err != nil {
 		log.Fatal(err)
 	}
+	if snap != nil {
+		go snap.Run(ctx, snapshotFlushInterval)
+	}
 
-	ctrl := controller.New(kc, rc, wrapper)
+	ctrl := controller.New(kc, crds, source, newManifoldClient, controller.Options{
+		Namespace:           opts.namespace,
+		Team:                team,
+		ResyncPeriod:        opts.resync,
+		CacheSyncTimeout:    opts.cacheSyncTimeout,
+		ShutdownGracePeriod: opts.shutdownGracePeriod,
+		Snapshot:            snap,
+		Throttle:            thr,
+		Rollout:             opts.rollout,
+		AdoptionPolicy:      adoption,
+		DeletionPolicy:      opts.deletionPolicy,
+	})
+	if reloader != nil {
+		reloader.update = ctrl.SetClient
+		go reloader.Run(ctx)
+	}
+
+	if opts.metricsAddr != "" {
+		go serveMetrics(opts.metricsAddr)
+	}
+
+	if opts.webhookAddr != "" {
+		go serveWebhook(opts.webhookAddr, opts.webhookCertFile, opts.webhookKeyFile, webhook.New(projectGetter(crds)))
+	}
+
+	health.setController(ctrl)
+
+	errs := make(chan error, 1)
 	go func() {
-		if err := ctrl.Run(ctx); err != nil {
-			log.WithError(err).Error("issue running the controller")
-		}
+		errs <- ctrl.Run(ctx)
 	}()
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	log.Info("Shutting down...")
+	select {
+	case <-quit:
+		log.Info("Shutting down...")
+		cancelFunc()
+		if err := <-errs; err != nil {
+			log.WithError(err).Error("issue shutting down the controller")
+		}
+
+		if snap != nil {
+			if err := snap.Flush(); err != nil {
+				log.WithError(err).Error("could not write the credentials snapshot")
+			}
+		}
+	case err := <-errs:
+		log.WithError(err).Fatal("issue running the controller")
+	}
 }
 
-func newClient(cfg *rest.Config) (*rest.RESTClient, error) {
-	scheme := runtime.NewScheme()
+// serveMetrics serves the Prometheus metrics on the given address.
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
 
-	if err := projects.AddToScheme(scheme); err != nil {
-		return nil, err
+	log.WithField("addr", addr).Info("Serving metrics")
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.WithError(err).Error("issue serving metrics")
 	}
+}
 
-	if err := resources.AddToScheme(scheme); err != nil {
-		return nil, err
+// serveWebhook serves the admission webhook over TLS on the given address.
+func serveWebhook(addr, certFile, keyFile string, h *webhook.Handler) {
+	mux := http.NewServeMux()
+	mux.Handle("/mutate", h)
+
+	log.WithField("addr", addr).Info("Serving admission webhook")
+	if err := http.ListenAndServeTLS(addr, certFile, keyFile, mux); err != nil {
+		log.WithError(err).Fatal("issue serving admission webhook")
 	}
+}
 
-	config := *cfg
-	config.GroupVersion = &crd.SchemeGroupVersion
-	config.APIPath = "/apis"
-	config.ContentType = runtime.ContentTypeJSON
-	config.NegotiatedSerializer = serializer.DirectCodecFactory{CodecFactory: serializer.NewCodecFactory(scheme)}
+// projectGetter returns a webhook.ProjectGetter which gets Projects from the
+// cluster.
+func projectGetter(crds versioned.Interface) webhook.ProjectGetter {
+	return func(namespace, name string) (*primitives.Project, error) {
+		return crds.ManifoldV1().Projects(namespace).Get(name, metav1.GetOptions{})
+	}
+}
+
+// newManifoldClient builds a Manifold integrations client for the given token
+// and team.
+func newManifoldClient(token, team string) (controller.CredentialSource, error) {
+	cl, err := integrations.NewClient(newManifoldAPIClient(token), &team)
+	if err != nil {
+		return nil, err
+	}
 
-	return rest.RESTClientFor(&config)
+	return cl, nil
 }
@@ -0,0 +1,361 @@
+// Package manifest reads Project and Resource manifests from YAML or JSON
+// files, keeping track of where each object was defined so problems can be
+// reported with a file and line.
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+// Object is a Project or Resource read from a manifest. Exactly one of Project
+// and Resource is set.
+type Object struct {
+	File     string
+	Line     int
+	Project  *primitives.Project
+	Resource *primitives.Resource
+
+	lines []string
+}
+
+// Kind returns the kind of the object.
+func (o *Object) Kind() string {
+	if o.Project != nil {
+		return primitives.CRDProjectsName
+	}
+
+	return primitives.CRDResourcesName
+}
+
+// Meta returns the metadata of the object.
+func (o *Object) Meta() *metav1.ObjectMeta {
+	if o.Project != nil {
+		return &o.Project.ObjectMeta
+	}
+
+	return &o.Resource.ObjectMeta
+}
+
+// LineOf returns the line of the first line of the object's document that
+// contains the given text, or the line the document starts on when there is
+// none.
+func (o *Object) LineOf(text string) int {
+	for i, l := range o.lines {
+		if strings.Contains(l, text) {
+			return o.Line + i
+		}
+	}
+
+	return o.Line
+}
+
+// lineOfField returns the line of the field with the given path, such as
+// spec.resources[1].credentials[0].key, by following the fields and list
+// items through the document. It returns the line of the closest parent it
+// could find.
+func (o *Object) lineOfField(path string) int {
+	idx := 0
+	for _, seg := range strings.Split(path, ".") {
+		name, item := seg, -1
+		if i := strings.Index(seg, "["); i >= 0 {
+			name = seg[:i]
+			item, _ = strconv.Atoi(strings.TrimSuffix(seg[i+1:], "]"))
+		}
+
+		next := -1
+		for j := idx; j < len(o.lines); j++ {
+			t := strings.TrimLeft(strings.TrimSpace(o.lines[j]), "- \"")
+			if strings.HasPrefix(t, name+":") || strings.HasPrefix(t, name+"\":") {
+				next = j
+				break
+			}
+		}
+		if next < 0 {
+			break
+		}
+		idx = next
+
+		if item >= 0 {
+			if j := o.listItem(idx, item); j >= 0 {
+				idx = j
+			}
+		}
+	}
+
+	return o.Line + idx
+}
+
+// listItem returns the index of the nth item of the list which starts after
+// the given line, or -1 if there is none.
+func (o *Object) listItem(start, n int) int {
+	indent, count := -1, -1
+	for j := start + 1; j < len(o.lines); j++ {
+		t := strings.TrimSpace(o.lines[j])
+		if t != "-" && !strings.HasPrefix(t, "- ") {
+			continue
+		}
+
+		ind := len(o.lines[j]) - len(strings.TrimLeft(o.lines[j], " "))
+		if indent < 0 {
+			indent = ind
+		}
+		if ind < indent {
+			return -1
+		}
+		if ind != indent {
+			continue
+		}
+
+		if count++; count == n {
+			return j
+		}
+	}
+
+	return -1
+}
+
+// Validate checks the object for problems the controller would run into
+// when writing its secret.
+func (o *Object) Validate() Errors {
+	var errs Errors
+	if o.Meta().Name == "" {
+		errs = append(errs, &Error{File: o.File, Line: o.lineOfField("metadata"), Message: "metadata.name: the name is required"})
+	}
+
+	var verrs []*primitives.ValidationError
+	switch {
+	case o.Project != nil && o.Project.Spec != nil:
+		verrs = o.Project.Spec.Validate()
+	case o.Resource != nil && o.Resource.Spec != nil:
+		verrs = o.Resource.Spec.Validate()
+	default:
+		return append(errs, &Error{File: o.File, Line: o.Line, Message: "spec: the spec is required"})
+	}
+
+	for _, e := range verrs {
+		// Fields which aren't set can't be found, so point at their parent.
+		field := e.Field
+		if e.Value == "" {
+			if i := strings.LastIndex(field, "."); i >= 0 {
+				field = field[:i]
+			}
+		}
+
+		errs = append(errs, &Error{File: o.File, Line: o.lineOfField(field), Message: e.Error()})
+	}
+
+	return errs
+}
+
+// Errorf returns an Error at the line of the given text within the object.
+func (o *Object) Errorf(text, format string, args ...interface{}) *Error {
+	return &Error{File: o.File, Line: o.LineOf(text), Message: fmt.Sprintf(format, args...)}
+}
+
+// Error is a problem with a manifest.
+type Error struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+}
+
+// Errors is a list of problems with manifests.
+type Errors []*Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// Load reads the Projects and Resources from the given files. Directories are
+// read recursively for .yml, .yaml and .json files. Other kinds of objects are
+// skipped. Documents that can't be read are returned as Errors, after the
+// objects that could be read.
+func Load(paths ...string) ([]*Object, error) {
+	var files []string
+	for _, p := range paths {
+		fs, err := expand(p)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, fs...)
+	}
+
+	var objects []*Object
+	var errs Errors
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+
+		objs, err := Read(file, f)
+		f.Close()
+		objects = append(objects, objs...)
+
+		switch err := err.(type) {
+		case nil:
+		case Errors:
+			errs = append(errs, err...)
+		default:
+			return nil, err
+		}
+	}
+
+	if len(errs) > 0 {
+		return objects, errs
+	}
+
+	return objects, nil
+}
+
+// expand returns the manifest files for the given path.
+func expand(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".yml", ".yaml", ".json":
+			if !info.IsDir() {
+				files = append(files, p)
+			}
+		}
+
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// Read reads the Projects and Resources from the documents in r, using name
+// as the file name.
+func Read(name string, r io.Reader) ([]*Object, error) {
+	bts, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []*Object
+	var errs Errors
+	for _, doc := range split(bts) {
+		obj, err := decode(name, doc)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if obj != nil {
+			objects = append(objects, obj)
+		}
+	}
+
+	if len(errs) > 0 {
+		return objects, errs
+	}
+
+	return objects, nil
+}
+
+// document is a single YAML document within a file.
+type document struct {
+	line  int
+	lines []string
+}
+
+// split splits a file into its YAML documents.
+func split(bts []byte) []document {
+	var docs []document
+	cur := document{line: 1}
+
+	scanner := bufio.NewScanner(bytes.NewReader(bts))
+	scanner.Buffer(make([]byte, 64*1024), len(bts)+1)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if strings.TrimRight(line, " \t") == "---" {
+			docs = append(docs, cur)
+			cur = document{line: n + 1}
+			continue
+		}
+
+		cur.lines = append(cur.lines, line)
+	}
+
+	return append(docs, cur)
+}
+
+func decode(name string, doc document) (*Object, *Error) {
+	// Report the document as starting on its first line of content, skipping
+	// blank lines and comments.
+	for len(doc.lines) > 0 {
+		l := strings.TrimSpace(doc.lines[0])
+		if l != "" && !strings.HasPrefix(l, "#") {
+			break
+		}
+		doc.line++
+		doc.lines = doc.lines[1:]
+	}
+
+	text := []byte(strings.Join(doc.lines, "\n"))
+
+	var tm metav1.TypeMeta
+	if err := yaml.Unmarshal(text, &tm); err != nil {
+		return nil, &Error{File: name, Line: doc.line, Message: fmt.Sprintf("could not parse document: %s", err)}
+	}
+
+	obj := &Object{File: name, Line: doc.line, lines: doc.lines}
+	if tm.APIVersion != primitives.CRDGroup+"/"+primitives.CRDVersion {
+		return nil, nil
+	}
+
+	var err error
+	switch tm.Kind {
+	case primitives.CRDProjectsName:
+		obj.Project = &primitives.Project{}
+		err = yaml.Unmarshal(text, obj.Project)
+	case primitives.CRDResourcesName:
+		obj.Resource = &primitives.Resource{}
+		err = yaml.Unmarshal(text, obj.Resource)
+	default:
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, &Error{File: name, Line: doc.line, Message: fmt.Sprintf("could not decode %s: %s", tm.Kind, err)}
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,123 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+)
+
+const manifests = `# credentials for the terraform project
+apiVersion: manifold.co/v1
+kind: Project
+metadata:
+  name: manifold-terraform-project
+spec:
+  project: manifold-terraform
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unrelated
+---
+apiVersion: manifold.co/v1
+kind: Resource
+metadata:
+  name: manifold-terraform-resource
+spec:
+  resource: custom-resource1
+  credentials:
+    - key: TOKEN_ID
+---
+apiVersion: manifold.co/v1
+kind: Resource
+spec: [not, a, spec]
+`
+
+func TestRead(t *testing.T) {
+	objects, err := Read("manifest.yml", strings.NewReader(manifests))
+
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("Expected a single error, got '%v'", err)
+	}
+	if errs[0].Line != 23 {
+		t.Errorf("Expected the error to be on line 23, got %d", errs[0].Line)
+	}
+
+	if len(objects) != 2 {
+		t.Fatalf("Expected 2 objects, got %d", len(objects))
+	}
+
+	tcs := []struct {
+		scenario string
+		kind     string
+		name     string
+		line     int
+	}{
+		{"with a project", "Project", "manifold-terraform-project", 2},
+		{"with a resource", "Resource", "manifold-terraform-resource", 14},
+	}
+
+	for i, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			obj := objects[i]
+			if obj.Kind() != tc.kind {
+				t.Errorf("Expected kind to be '%s', got '%s'", tc.kind, obj.Kind())
+			}
+			if obj.Meta().Name != tc.name {
+				t.Errorf("Expected name to be '%s', got '%s'", tc.name, obj.Meta().Name)
+			}
+			if obj.Line != tc.line {
+				t.Errorf("Expected line to be %d, got %d", tc.line, obj.Line)
+			}
+		})
+	}
+
+	if line := objects[1].LineOf("TOKEN_ID"); line != 21 {
+		t.Errorf("Expected TOKEN_ID to be on line 21, got %d", line)
+	}
+}
+
+const invalid = `apiVersion: manifold.co/v1
+kind: Project
+metadata:
+  name: manifold-terraform-project
+spec:
+  project: manifold-terraform
+  type: tls
+  resources:
+    - resource: custom-resource1
+      credentials:
+        - key: TOKEN_ID
+        - key: TOKEN_SECRET
+          encoding: base32
+    - credentials:
+        - key: TOKEN_ID
+`
+
+func TestValidate(t *testing.T) {
+	objects, err := Read("invalid.yml", strings.NewReader(invalid))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	errs := objects[0].Validate()
+	expected := []struct {
+		line  int
+		field string
+	}{
+		{7, "spec.type"},
+		{13, "spec.resources[0].credentials[1].encoding"},
+		{14, "spec.resources[1]"},
+		{15, "spec.resources[1].credentials[0].key"},
+	}
+
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, got '%s'", len(expected), errs)
+	}
+
+	for i, e := range expected {
+		if errs[i].Line != e.line || !strings.HasPrefix(errs[i].Message, e.field) {
+			t.Errorf("Expected %s on line %d, got '%s'", e.field, e.line, errs[i])
+		}
+	}
+}
@@ -0,0 +1,20 @@
+// Package manifoldtest provides a stand-in for the Manifold API, for tests that
+// run the controller end to end without network access.
+//
+// The Server implements the identity and marketplace endpoints the
+// integrations client of go-manifold uses to resolve teams, projects, resources
+// and credentials. It's backed by the same fixtures as the filesource package,
+// nested by team, project and resource label:
+//
+//	teams:
+//	  manifold:
+//	    manifold-terraform:
+//	      custom-resource1:
+//	        TOKEN_ID: my-token-id
+//
+// Requests without a team are served from the server's default team. Latency,
+// server errors, the HTML error pages of a proxy and rate limited responses
+// can be injected to exercise the retry and caching behaviour of the
+// controller, and credentials can be changed while the server is running to
+// simulate a rotation.
+package manifoldtest
@@ -0,0 +1,613 @@
+package manifoldtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/manifoldco/go-manifold"
+	"github.com/manifoldco/go-manifold/idtype"
+
+	"github.com/manifoldco/kubernetes-credentials/filesource"
+)
+
+// Endpoints served by the Server, as passed to Requests.
+const (
+	EndpointSelf        = "self"
+	EndpointTeams       = "teams"
+	EndpointProjects    = "projects"
+	EndpointResources   = "resources"
+	EndpointCredentials = "credentials"
+)
+
+// object is a Manifold API object as it's sent over the wire.
+type object struct {
+	ID      string                 `json:"id"`
+	Version int                    `json:"version"`
+	Type    string                 `json:"type"`
+	Body    map[string]interface{} `json:"body"`
+}
+
+// apiError is the body of a failed Manifold API request.
+type apiError struct {
+	Type    string   `json:"type"`
+	Message []string `json:"message"`
+}
+
+type resource struct {
+	id           string
+	credentialID string
+	label        string
+	credentials  filesource.Credentials
+}
+
+type project struct {
+	id        string
+	label     string
+	resources map[string]*resource
+}
+
+type team struct {
+	id       string
+	label    string
+	projects map[string]*project
+}
+
+// fault is an injected failure for the next requests.
+type fault struct {
+	remaining  int
+	status     int
+	retryAfter time.Duration
+	html       bool
+}
+
+// Request is a request the server responded to.
+type Request struct {
+	Endpoint  string
+	Status    int
+	Received  time.Time
+	Responded time.Time
+}
+
+// Server is a stand-in for the Manifold API backed by fixtures. It's safe for
+// concurrent use.
+type Server struct {
+	// URL is the base URL of the server, of the form http://ipaddr:port with
+	// no trailing slash.
+	URL string
+
+	srv         *httptest.Server
+	defaultTeam string
+	userID      string
+
+	mu       sync.Mutex
+	token    string
+	teams    map[string]*team
+	latency  time.Duration
+	faults   []*fault
+	requests map[string]int
+	log      []Request
+}
+
+// NewServer starts a Server for the given teams, in the same structure as the
+// filesource package. Requests without a team are served from the given
+// default team. The caller should call Close when finished, to shut it down.
+func NewServer(defaultTeam string, teams map[string]filesource.Team) *Server {
+	s := &Server{
+		defaultTeam: defaultTeam,
+		userID:      newID(idtype.User),
+		teams:       map[string]*team{},
+		requests:    map[string]int{},
+	}
+
+	for tl, t := range teams {
+		for pl, p := range t {
+			for rl, creds := range p {
+				s.setCredentials(tl, pl, rl, creds)
+			}
+		}
+	}
+
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Load starts a Server for the fixtures in the file or directory at the given
+// path, in the format of the filesource package.
+func Load(path, defaultTeam string) (*Server, error) {
+	teams, err := filesource.LoadTeams(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewServer(defaultTeam, teams), nil
+}
+
+// Close shuts down the server and blocks until all outstanding requests on
+// this server have completed.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// URLPattern returns the URL pattern to point a Manifold client at this
+// server, with manifold.ForURLPattern.
+func (s *Server) URLPattern() string {
+	return s.URL + "/%s/v1"
+}
+
+// Client returns a Manifold client for this server with the given token.
+func (s *Server) Client(token string) *manifold.Client {
+	return manifold.New(
+		manifold.WithAPIToken(token),
+		manifold.ForURLPattern(s.URLPattern()),
+	)
+}
+
+// SetToken sets the API token requests need to be authorized with. Any token
+// is accepted when it's empty, which is the default.
+func (s *Server) SetToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.token = token
+}
+
+// SetCredentials replaces the credentials of a resource, creating the team,
+// project and resource if they don't exist yet. The credentials of an existing
+// resource keep their ID, as they do when they're rotated.
+func (s *Server) SetCredentials(team, project, resource string, creds filesource.Credentials) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.setCredentials(team, project, resource, creds)
+}
+
+func (s *Server) setCredentials(tl, pl, rl string, creds filesource.Credentials) {
+	t, ok := s.teams[tl]
+	if !ok {
+		t = &team{id: newID(idtype.Team), label: tl, projects: map[string]*project{}}
+		s.teams[tl] = t
+	}
+
+	p, ok := t.projects[pl]
+	if !ok {
+		p = &project{id: newID(idtype.Project), label: pl, resources: map[string]*resource{}}
+		t.projects[pl] = p
+	}
+
+	r, ok := p.resources[rl]
+	if !ok {
+		r = &resource{id: newID(idtype.Resource), credentialID: newID(idtype.Credential), label: rl}
+		p.resources[rl] = r
+	}
+
+	r.credentials = make(filesource.Credentials, len(creds))
+	for k, v := range creds {
+		r.credentials[k] = v
+	}
+}
+
+// SetLatency delays every response by the given duration.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latency = d
+}
+
+// FailNext fails the next n requests with the given status code, which is
+// usually a 5xx. Faults are applied in the order they were injected.
+func (s *Server) FailNext(n, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &fault{remaining: n, status: status})
+}
+
+// FailNextHTML fails the next n requests with the given status code and an
+// HTML error page, like a proxy or load balancer in front of the API does when
+// it can't reach it. Unlike the API's own errors, the body isn't JSON.
+func (s *Server) FailNextHTML(n, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &fault{remaining: n, status: status, html: true})
+}
+
+// RateLimitNext responds to the next n requests with a 429 and the given
+// Retry-After, rounded up to whole seconds. No Retry-After header is sent when
+// it's zero.
+func (s *Server) RateLimitNext(n int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &fault{remaining: n, status: http.StatusTooManyRequests, retryAfter: retryAfter})
+}
+
+// ClearFaults removes the injected failures and latency.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+	s.latency = 0
+}
+
+// Requests returns the number of requests the server received for the given
+// endpoint, including the ones that were failed on purpose.
+func (s *Server) Requests(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[endpoint]
+}
+
+// Log returns the requests the server responded to, in the order their
+// responses were written, including the ones that were failed on purpose.
+// The timestamps let tests check how long a client paused between requests.
+func (s *Server) Log() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.log...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	// Paths are of the form /<service>/v1/<endpoint>[/<id>], following the
+	// URL pattern.
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 3 || parts[1] != "v1" {
+		writeError(w, http.StatusNotFound, "not_found", "unknown path '%s'", r.URL.Path)
+		return
+	}
+	service, endpoint, id := parts[0], parts[2], ""
+	if len(parts) > 3 {
+		id = parts[3]
+	}
+
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	w = sw
+	defer s.record(endpoint, time.Now(), sw)
+
+	s.mu.Lock()
+	s.requests[endpoint]++
+	latency := s.latency
+	f := s.nextFault()
+	token := s.token
+	s.mu.Unlock()
+
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+
+	if f != nil && f.html {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(f.status)
+		fmt.Fprintf(w, "<html><body><h1>%d %s</h1></body></html>\n", f.status, http.StatusText(f.status))
+		return
+	}
+
+	if f != nil {
+		if f.retryAfter > 0 {
+			secs := int((f.retryAfter + time.Second - 1) / time.Second)
+			w.Header().Set("Retry-After", strconv.Itoa(secs))
+		}
+		typ := "internal"
+		if f.status == http.StatusTooManyRequests {
+			typ = "too_many_requests"
+		}
+		writeError(w, f.status, typ, "injected failure")
+		return
+	}
+
+	if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+		writeError(w, http.StatusUnauthorized, "unauthorized", "invalid token")
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "the stand-in server is read only")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []object
+	switch {
+	case service == "identity" && endpoint == EndpointSelf:
+		writeJSON(w, http.StatusOK, s.user())
+		return
+	case service == "identity" && endpoint == EndpointTeams:
+		list = s.listTeams()
+	case service == "marketplace" && endpoint == EndpointProjects:
+		list = s.listProjects(r)
+	case service == "marketplace" && endpoint == EndpointResources:
+		list = s.listResources(r)
+	case service == "marketplace" && endpoint == EndpointCredentials:
+		list = s.listCredentials(r)
+	default:
+		writeError(w, http.StatusNotFound, "not_found", "unknown path '%s'", r.URL.Path)
+		return
+	}
+
+	if id == "" {
+		writeJSON(w, http.StatusOK, list)
+		return
+	}
+
+	for _, o := range list {
+		if o.ID == id {
+			writeJSON(w, http.StatusOK, o)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "not_found", "%s '%s' not found", endpoint, id)
+}
+
+// record adds a responded request to the log.
+func (s *Server) record(endpoint string, received time.Time, sw *statusWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log = append(s.log, Request{Endpoint: endpoint, Status: sw.status, Received: received, Responded: time.Now()})
+}
+
+// statusWriter remembers the status code of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// nextFault returns the injected failure for the current request, if any. The
+// lock has to be held.
+func (s *Server) nextFault() *fault {
+	for len(s.faults) > 0 {
+		f := s.faults[0]
+		if f.remaining <= 0 {
+			s.faults = s.faults[1:]
+			continue
+		}
+
+		f.remaining--
+		return f
+	}
+
+	return nil
+}
+
+func (s *Server) user() object {
+	return object{
+		ID:      s.userID,
+		Version: 1,
+		Type:    "user",
+		Body: map[string]interface{}{
+			"name":  "Stand-in User",
+			"email": "stand-in@manifold.test",
+		},
+	}
+}
+
+func (s *Server) listTeams() []object {
+	list := []object{}
+	for _, t := range s.sortedTeams() {
+		list = append(list, object{
+			ID:      t.id,
+			Version: 1,
+			Type:    "team",
+			Body: map[string]interface{}{
+				"name":  t.label,
+				"label": t.label,
+			},
+		})
+	}
+
+	return list
+}
+
+func (s *Server) listProjects(r *http.Request) []object {
+	label := r.URL.Query().Get("label")
+
+	list := []object{}
+	for _, t := range s.requestTeams(r) {
+		for _, p := range sortedProjects(t) {
+			if label != "" && p.label != label {
+				continue
+			}
+
+			list = append(list, object{
+				ID:      p.id,
+				Version: 1,
+				Type:    "project",
+				Body: s.owned(t, map[string]interface{}{
+					"name":  p.label,
+					"label": p.label,
+				}),
+			})
+		}
+	}
+
+	return list
+}
+
+func (s *Server) listResources(r *http.Request) []object {
+	q := r.URL.Query()
+	label := q.Get("label")
+	projectID := q.Get("project_id")
+
+	list := []object{}
+	for _, t := range s.requestTeams(r) {
+		for _, p := range sortedProjects(t) {
+			if projectID != "" && p.id != projectID {
+				continue
+			}
+
+			for _, res := range sortedResources(p) {
+				if label != "" && res.label != label {
+					continue
+				}
+
+				list = append(list, object{
+					ID:      res.id,
+					Version: 1,
+					Type:    "resource",
+					Body: s.owned(t, map[string]interface{}{
+						"name":       res.label,
+						"label":      res.label,
+						"project_id": p.id,
+						"source":     "custom",
+					}),
+				})
+			}
+		}
+	}
+
+	return list
+}
+
+func (s *Server) listCredentials(r *http.Request) []object {
+	ids := map[string]bool{}
+	for _, v := range r.URL.Query()["resource_id"] {
+		for _, id := range strings.Split(v, ",") {
+			ids[id] = true
+		}
+	}
+
+	list := []object{}
+	for _, t := range s.sortedTeams() {
+		for _, p := range sortedProjects(t) {
+			for _, res := range sortedResources(p) {
+				if !ids[res.id] {
+					continue
+				}
+
+				values := make(map[string]string, len(res.credentials))
+				for k, v := range res.credentials {
+					values[k] = v
+				}
+
+				list = append(list, object{
+					ID:      res.credentialID,
+					Version: 1,
+					Type:    "credential",
+					Body: map[string]interface{}{
+						"resource_id": res.id,
+						"values":      values,
+					},
+				})
+			}
+		}
+	}
+
+	return list
+}
+
+// requestTeams returns the teams a request is scoped to: the one given by the
+// team_id parameter, or the default team.
+func (s *Server) requestTeams(r *http.Request) []*team {
+	if id := r.URL.Query().Get("team_id"); id != "" {
+		for _, t := range s.teams {
+			if t.id == id {
+				return []*team{t}
+			}
+		}
+
+		return nil
+	}
+
+	if t, ok := s.teams[s.defaultTeam]; ok {
+		return []*team{t}
+	}
+
+	return nil
+}
+
+// owned sets the owner of an object body, which is the user for objects in
+// the default team.
+func (s *Server) owned(t *team, body map[string]interface{}) map[string]interface{} {
+	if t.label == s.defaultTeam {
+		body["user_id"] = s.userID
+	} else {
+		body["team_id"] = t.id
+	}
+
+	return body
+}
+
+func (s *Server) sortedTeams() []*team {
+	labels := make([]string, 0, len(s.teams))
+	for l := range s.teams {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+
+	teams := make([]*team, len(labels))
+	for i, l := range labels {
+		teams[i] = s.teams[l]
+	}
+
+	return teams
+}
+
+func sortedProjects(t *team) []*project {
+	labels := make([]string, 0, len(t.projects))
+	for l := range t.projects {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+
+	projects := make([]*project, len(labels))
+	for i, l := range labels {
+		projects[i] = t.projects[l]
+	}
+
+	return projects
+}
+
+func sortedResources(p *project) []*resource {
+	labels := make([]string, 0, len(p.resources))
+	for l := range p.resources {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+
+	resources := make([]*resource, len(labels))
+	for i, l := range labels {
+		resources[i] = p.resources[l]
+	}
+
+	return resources
+}
+
+func newID(t idtype.Type) string {
+	id, err := manifold.NewID(t)
+	if err != nil {
+		panic(fmt.Sprintf("could not generate a Manifold ID: %s", err))
+	}
+
+	return id.String()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	// Writing only fails when the client went away, so there's nobody to
+	// report the error to.
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, typ, format string, args ...interface{}) {
+	writeJSON(w, status, apiError{Type: typ, Message: []string{fmt.Sprintf(format, args...)}})
+}
@@ -0,0 +1,237 @@
+package manifoldtest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/manifoldco/go-manifold/integrations"
+	"github.com/manifoldco/go-manifold/integrations/primitives"
+
+	"github.com/manifoldco/kubernetes-credentials/filesource"
+)
+
+func testServer() *Server {
+	return NewServer("manifold", map[string]filesource.Team{
+		"manifold": {
+			"production": {
+				"db":    {"USERNAME": "prod-user", "PASSWORD": "prod-pass"},
+				"cache": {"URL": "redis://prod"},
+			},
+		},
+		"other": {
+			"staging": {
+				"db": {"USERNAME": "other-user"},
+			},
+		},
+	})
+}
+
+func get(t *testing.T, s *Server, path string, v interface{}) *http.Response {
+	t.Helper()
+
+	resp, err := http.Get(s.URL + path)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	defer resp.Body.Close()
+
+	if v != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("could not decode the response: %s", err)
+		}
+	}
+
+	return resp
+}
+
+func TestServer_Endpoints(t *testing.T) {
+	s := testServer()
+	defer s.Close()
+
+	var teams []object
+	get(t, s, "/identity/v1/teams", &teams)
+	if len(teams) != 2 || teams[0].Body["label"] != "manifold" || teams[1].Body["label"] != "other" {
+		t.Fatalf("expected the manifold and other teams, got %v", teams)
+	}
+
+	t.Run("projects of the default team", func(t *testing.T) {
+		var projects []object
+		get(t, s, "/marketplace/v1/projects?label=production", &projects)
+		if len(projects) != 1 || projects[0].Body["label"] != "production" {
+			t.Fatalf("expected the production project, got %v", projects)
+		}
+	})
+
+	t.Run("projects of another team", func(t *testing.T) {
+		var projects []object
+		get(t, s, "/marketplace/v1/projects?team_id="+teams[1].ID, &projects)
+		if len(projects) != 1 || projects[0].Body["label"] != "staging" {
+			t.Fatalf("expected the staging project, got %v", projects)
+		}
+	})
+
+	t.Run("resources and credentials", func(t *testing.T) {
+		var resources []object
+		get(t, s, "/marketplace/v1/resources?label=cache", &resources)
+		if len(resources) != 1 {
+			t.Fatalf("expected the cache resource, got %v", resources)
+		}
+
+		var creds []object
+		get(t, s, "/marketplace/v1/credentials?resource_id="+resources[0].ID, &creds)
+		if len(creds) != 1 {
+			t.Fatalf("expected 1 credential, got %v", creds)
+		}
+
+		values := creds[0].Body["values"].(map[string]interface{})
+		if values["URL"] != "redis://prod" {
+			t.Errorf("expected URL to eq %q, got %q", "redis://prod", values["URL"])
+		}
+	})
+
+	t.Run("a single object", func(t *testing.T) {
+		var team object
+		get(t, s, "/identity/v1/teams/"+teams[1].ID, &team)
+		if team.ID != teams[1].ID {
+			t.Errorf("expected team %q, got %q", teams[1].ID, team.ID)
+		}
+
+		resp := get(t, s, "/identity/v1/teams/unknown", nil)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, resp.StatusCode)
+		}
+	})
+
+	if n := s.Requests(EndpointTeams); n != 3 {
+		t.Errorf("expected 3 team requests, got %d", n)
+	}
+}
+
+func TestServer_Faults(t *testing.T) {
+	s := testServer()
+	defer s.Close()
+
+	s.FailNext(1, http.StatusServiceUnavailable)
+	s.FailNextHTML(1, http.StatusBadGateway)
+	s.RateLimitNext(1, 1500*time.Millisecond)
+
+	resp := get(t, s, "/identity/v1/self", nil)
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, resp.StatusCode)
+	}
+
+	resp = get(t, s, "/identity/v1/self", nil)
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("expected status %d, got %d", http.StatusBadGateway, resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("expected an HTML body, got content type %q", ct)
+	}
+
+	resp = get(t, s, "/identity/v1/self", nil)
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected status %d, got %d", http.StatusTooManyRequests, resp.StatusCode)
+	}
+	if ra := resp.Header.Get("Retry-After"); ra != "2" {
+		t.Errorf("expected Retry-After to eq %q, got %q", "2", ra)
+	}
+
+	resp = get(t, s, "/identity/v1/self", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	log := s.Log()
+	expected := []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusTooManyRequests, http.StatusOK}
+	if len(log) != len(expected) {
+		t.Fatalf("expected %d logged requests, got %d", len(expected), len(log))
+	}
+	for i, req := range log {
+		if req.Endpoint != EndpointSelf || req.Status != expected[i] {
+			t.Errorf("expected request %d to eq %s %d, got %s %d", i, EndpointSelf, expected[i], req.Endpoint, req.Status)
+		}
+		if req.Responded.Before(req.Received) {
+			t.Errorf("expected request %d to be responded to after it was received", i)
+		}
+	}
+
+	t.Run("latency", func(t *testing.T) {
+		s.SetLatency(50 * time.Millisecond)
+		defer s.ClearFaults()
+
+		start := time.Now()
+		get(t, s, "/identity/v1/self", nil)
+		if d := time.Since(start); d < 50*time.Millisecond {
+			t.Errorf("expected the response to take at least 50ms, took %s", d)
+		}
+	})
+
+	t.Run("token", func(t *testing.T) {
+		s.SetToken("secret")
+		defer s.SetToken("")
+
+		resp := get(t, s, "/identity/v1/self", nil)
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected status %d, got %d", http.StatusUnauthorized, resp.StatusCode)
+		}
+	})
+}
+
+func TestServer_Integrations(t *testing.T) {
+	s := testServer()
+	defer s.Close()
+
+	tcs := []struct {
+		scenario string
+		team     string
+		project  string
+		expected string
+	}{
+		{scenario: "default team", project: "production", expected: "prod-user"},
+		{scenario: "other team", team: "other", project: "staging", expected: "other-user"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			team := tc.team
+			cl, err := integrations.NewClient(s.Client("token"), &team)
+			if err != nil {
+				t.Fatalf("expected no error, got %q", err)
+			}
+
+			cvs, err := cl.GetResourceCredentialValues(context.Background(), &tc.project, &primitives.Resource{
+				Name:        "db",
+				Credentials: []*primitives.Credential{{Key: "USERNAME"}},
+			})
+			if err != nil {
+				t.Fatalf("expected no error, got %q", err)
+			}
+			if len(cvs) != 1 || cvs[0].Value != tc.expected {
+				t.Fatalf("expected USERNAME to eq %q, got %v", tc.expected, cvs)
+			}
+		})
+	}
+
+	t.Run("rotation", func(t *testing.T) {
+		team := ""
+		cl, err := integrations.NewClient(s.Client("token"), &team)
+		if err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+
+		s.SetCredentials("manifold", "production", "cache", filesource.Credentials{"URL": "redis://rotated"})
+
+		project := "production"
+		cvs, err := cl.GetResourceCredentialValues(context.Background(), &project, &primitives.Resource{Name: "cache"})
+		if err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+		if len(cvs) != 1 || cvs[0].Value != "redis://rotated" {
+			t.Fatalf("expected URL to eq %q, got %v", "redis://rotated", cvs)
+		}
+	})
+}
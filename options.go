@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/manifoldco/kubernetes-credentials/controller"
+	"github.com/manifoldco/kubernetes-credentials/logging"
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+	"github.com/manifoldco/kubernetes-credentials/throttle"
+)
+
+// options are the command line options for the controller.
+type options struct {
+	kubeconfig string
+	context    string
+	master     string
+	namespace  string
+	resync     time.Duration
+	logLevel   string
+	logFormat  string
+
+	cacheSyncTimeout    time.Duration
+	shutdownGracePeriod time.Duration
+
+	credentialsFile     string
+	tokenReloadInterval time.Duration
+
+	snapshotSecret       string
+	snapshotFile         string
+	snapshotKeyFile      string
+	snapshotMaxStaleness time.Duration
+
+	rollout        bool
+	adoptionPolicy string
+	deletionPolicy string
+
+	apiQPS               float64
+	apiBurst             int
+	breakerThreshold     int
+	breakerProbeInterval time.Duration
+
+	webhookAddr     string
+	webhookCertFile string
+	webhookKeyFile  string
+
+	metricsAddr   string
+	healthAddr    string
+	maxAPIFailing time.Duration
+}
+
+// register adds the options as flags to the given FlagSet.
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.kubeconfig, "kubeconfig", "", "Path to a kubeconfig file. Only required when running out of cluster.")
+	fs.StringVar(&o.context, "context", "", "The kubeconfig context to use.")
+	fs.StringVar(&o.master, "master", "", "The address of the Kubernetes API server. Overrides the server in the kubeconfig.")
+	fs.StringVar(&o.namespace, "namespace", "", "Only watch Projects and Resources in this namespace. Watches all namespaces by default.")
+	fs.DurationVar(&o.resync, "resync", 10*time.Second, "How often all Projects and Resources are reconciled.")
+	fs.DurationVar(&o.cacheSyncTimeout, "cache-sync-timeout", 2*time.Minute, "How long to wait for the informer caches to sync on startup.")
+	fs.DurationVar(&o.shutdownGracePeriod, "shutdown-grace-period", 30*time.Second, "How long to wait for in-flight reconciles to finish on shutdown.")
+	fs.StringVar(&o.logLevel, "log-level", log.InfoLevel.String(), "The log level: debug, info, warn or error.")
+	fs.StringVar(&o.logFormat, "log-format", logging.FormatText, "The log format: text or json.")
+	fs.StringVar(&o.credentialsFile, "credentials-file", "", "Load credentials from this local file or directory instead of the Manifold API.")
+	fs.DurationVar(&o.tokenReloadInterval, "token-reload-interval", 30*time.Second, "How often MANIFOLD_API_TOKEN_FILE and MANIFOLD_TEAM_FILE are checked for changes.")
+	fs.StringVar(&o.snapshotSecret, "snapshot-secret", "", "Keep a last known good snapshot of the credentials in this namespace/name Secret.")
+	fs.StringVar(&o.snapshotFile, "snapshot-file", "", "Keep a last known good snapshot of the credentials in this file.")
+	fs.StringVar(&o.snapshotKeyFile, "snapshot-key-file", "", "File holding the base64 encoded 32 byte key used to encrypt the snapshot.")
+	fs.DurationVar(&o.snapshotMaxStaleness, "snapshot-max-staleness", 24*time.Hour, "How old snapshotted credentials can be and still be served.")
+	fs.BoolVar(&o.rollout, "rollout", false, "Roll out the Deployments, StatefulSets and DaemonSets that use a secret when its data changes.")
+	fs.StringVar(&o.adoptionPolicy, "adoption-policy", string(controller.AdoptNever), "Whether existing secrets without an owner are taken over: never, ifLabeled or always.")
+	fs.StringVar(&o.deletionPolicy, "deletion-policy", primitives.DeletionPolicyDelete, "What happens to the secret of a deleted Project or Resource without spec.deletionPolicy: Delete, Retain or Orphan.")
+	fs.Float64Var(&o.apiQPS, "api-qps", 5, "The average number of Manifold API requests per second. Unlimited when 0.")
+	fs.IntVar(&o.apiBurst, "api-burst", 10, "The number of Manifold API requests which can be made at once.")
+	fs.IntVar(&o.breakerThreshold, "breaker-threshold", 5, "The number of consecutive failed Manifold API requests after which requests are stopped. Disabled when 0.")
+	fs.DurationVar(&o.breakerProbeInterval, "breaker-probe-interval", 30*time.Second, "How often the Manifold API is probed while calls are stopped.")
+	fs.StringVar(&o.webhookAddr, "webhook-addr", "", "The address the admission webhook listens on. Disabled when empty.")
+	fs.StringVar(&o.webhookCertFile, "webhook-cert-file", "", "The TLS certificate of the admission webhook.")
+	fs.StringVar(&o.webhookKeyFile, "webhook-key-file", "", "The TLS key of the admission webhook.")
+	fs.StringVar(&o.metricsAddr, "metrics-addr", ":8080", "The address the Prometheus metrics endpoint listens on. Disabled when empty.")
+	fs.StringVar(&o.healthAddr, "health-addr", ":8081", "The address the /healthz and /readyz probes listen on. Disabled when empty.")
+	fs.DurationVar(&o.maxAPIFailing, "max-api-failing", 10*time.Minute, "How long Manifold API calls can fail before the controller is no longer ready.")
+}
+
+// setupLogging configures the global logger.
+func (o *options) setupLogging() error {
+	return logging.Setup(o.logFormat, o.logLevel)
+}
+
+// throttle returns the rate limiter and circuit breaker for the Manifold API
+// requests.
+func (o *options) throttle() *throttle.Throttle {
+	return &throttle.Throttle{
+		Limiter: throttle.NewLimiter(o.apiQPS, o.apiBurst),
+		Breaker: throttle.NewBreaker(o.breakerThreshold, o.breakerProbeInterval),
+	}
+}
+
+// restConfig returns the configuration to talk to the Kubernetes API. Without
+// any of the kubeconfig, context or master flags, we assume we're running
+// within the cluster.
+func (o *options) restConfig() (*rest.Config, error) {
+	if o.kubeconfig == "" && o.context == "" && o.master == "" {
+		return rest.InClusterConfig()
+	}
+
+	return o.clientConfig().ClientConfig()
+}
+
+// clientConfig returns the kubeconfig configuration for the kubeconfig,
+// context and master flags. Without them, the default kubeconfig is used.
+func (o *options) clientConfig() clientcmd.ClientConfig {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = o.kubeconfig
+
+	overrides := &clientcmd.ConfigOverrides{
+		CurrentContext: o.context,
+	}
+	overrides.ClusterInfo.Server = o.master
+
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+}
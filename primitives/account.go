@@ -0,0 +1,62 @@
+package primitives
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Default keys that are used to look up the Manifold credentials in the
+// Secret referenced by a ManifoldAccount.
+const (
+	DefaultAccountTokenKey = "api_token"
+	DefaultAccountTeamKey  = "team"
+)
+
+// ManifoldAccount is the manifest representation of a manifold.co
+// ManifoldAccount CRD. It references a Secret within the same namespace which
+// holds the Manifold API token, and optionally the team, that Projects and
+// Resources in that namespace can use to load their credentials.
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ManifoldAccount struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              *ManifoldAccountSpec `json:"spec"`
+}
+
+// ManifoldAccountList represents a list of available ManifoldAccounts in the
+// cluster.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ManifoldAccountList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []*ManifoldAccount `json:"items"`
+}
+
+// ManifoldAccountSpec is the specification that is required to build a valid
+// ManifoldAccount manifest.
+type ManifoldAccountSpec struct {
+	SecretName string `json:"secretName"`
+	TokenKey   string `json:"tokenKey,omitempty"`
+	TeamKey    string `json:"teamKey,omitempty"`
+}
+
+// SecretTokenKey returns the key in the referenced Secret that holds the
+// Manifold API token.
+func (as *ManifoldAccountSpec) SecretTokenKey() string {
+	if as.TokenKey == "" {
+		return DefaultAccountTokenKey
+	}
+
+	return as.TokenKey
+}
+
+// SecretTeamKey returns the key in the referenced Secret that holds the
+// Manifold team label.
+func (as *ManifoldAccountSpec) SecretTeamKey() string {
+	if as.TeamKey == "" {
+		return DefaultAccountTeamKey
+	}
+
+	return as.TeamKey
+}
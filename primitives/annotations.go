@@ -0,0 +1,70 @@
+package primitives
+
+// Annotations on Projects and Resources which control how the controller
+// handles them.
+const (
+	// AnnotationRefreshAt forces the credentials to be fetched and the secret
+	// to be written again. Every new value triggers a single refresh, by
+	// convention the time the refresh was requested at.
+	AnnotationRefreshAt = "credentials.manifold.co/refresh-at"
+
+	// AnnotationPaused stops the controller from reconciling the object while
+	// it's set to "true". Deleting the object still deletes its secret.
+	AnnotationPaused = "credentials.manifold.co/paused"
+)
+
+// Annotations on secrets which are written by the controller.
+const (
+	// AnnotationAdopt allows the controller to take over an existing secret
+	// which isn't owned by any controller when it's set to "true" and the
+	// adoption policy is ifLabeled.
+	AnnotationAdopt = "credentials.manifold.co/adopt"
+
+	// AnnotationManagedKeys lists the keys the controller manages in a secret
+	// which is written with the merge strategy, separated by commas.
+	AnnotationManagedKeys = "credentials.manifold.co/managed-keys"
+
+	// AnnotationManagedBy holds the kind and name of the object whose keys are
+	// listed in the managed keys annotation, for example Project/my-project.
+	AnnotationManagedBy = "credentials.manifold.co/managed-by"
+
+	// AnnotationOrphanedFrom marks a secret which was kept by the Retain
+	// deletion policy with the kind and name of the deleted object, for
+	// example Project/my-project.
+	AnnotationOrphanedFrom = "credentials.manifold.co/orphaned-from"
+)
+
+// FinalizerSecret is set on Projects and Resources whose secret is kept when
+// they're deleted, so the controller can release the secret before the
+// garbage collector deletes it along with its owner.
+const FinalizerSecret = "credentials.manifold.co/secret"
+
+// Annotations on workloads which are rolled out when a secret changes.
+const (
+	// AnnotationSecrets lists the secrets a Deployment, StatefulSet or
+	// DaemonSet uses, separated by commas, in addition to the ones referenced
+	// in its pod spec.
+	AnnotationSecrets = "credentials.manifold.co/secrets"
+
+	// AnnotationSecretHashPrefix, followed by a secret name, is set on the pod
+	// template of a workload with the content hash of that secret whenever its
+	// data changes, which rolls the workload out. Names which don't fit in an
+	// annotation key are shortened.
+	AnnotationSecretHashPrefix = "credentials.manifold.co/secret-hash."
+)
+
+// Annotations on pods which get credentials injected by the admission webhook.
+const (
+	// AnnotationProject names the Project, in the namespace of the pod, whose
+	// secret is injected into the containers of the pod.
+	AnnotationProject = "credentials.manifold.co/project"
+
+	// AnnotationMountPath mounts the secret as a volume at this path in every
+	// container of the pod.
+	AnnotationMountPath = "credentials.manifold.co/mount-path"
+
+	// AnnotationKeysPrefix, followed by a container name, selects the keys of
+	// the secret which are injected into that container, separated by commas.
+	// Containers without it get all keys.
+	AnnotationKeysPrefix = "credentials.manifold.co/keys."
+)
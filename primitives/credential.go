@@ -2,6 +2,10 @@ package primitives
 
 import "github.com/manifoldco/go-manifold/integrations/primitives"
 
+// EncodingBase64 decodes base64 encoded credential values before they're
+// written to the secret. It's the only supported encoding.
+const EncodingBase64 = "base64"
+
 // CredentialSpec represents the specification that is required to filter out
 // specific credentials in the Resource spec.
 type CredentialSpec struct {
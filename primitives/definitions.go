@@ -10,4 +10,10 @@ const (
 
 	CRDResourcesPlural = "resources"
 	CRDResourcesName   = "Resource"
+
+	CRDAccountsPlural = "manifoldaccounts"
+	CRDAccountsName   = "ManifoldAccount"
+
+	CRDPoliciesPlural = "credentialpolicies"
+	CRDPoliciesName   = "CredentialPolicy"
 )
@@ -1,4 +1,5 @@
 // +k8s:deepcopy-gen=package
+// +groupName=manifold.co
 
 // Package primitives contains data types for interacting with Manifold.
 package primitives
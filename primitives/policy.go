@@ -0,0 +1,135 @@
+package primitives
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// CredentialPolicy is the manifest representation of a manifold.co
+// CredentialPolicy CRD. Policies are cluster scoped and define which Manifold
+// teams, projects and resources the matching namespaces are allowed to load
+// credentials for.
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type CredentialPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              *CredentialPolicySpec `json:"spec"`
+}
+
+// CredentialPolicyList represents a list of available CredentialPolicies in
+// the cluster.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type CredentialPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []*CredentialPolicy `json:"items"`
+}
+
+// CredentialPolicySpec is the specification that is required to build a valid
+// CredentialPolicy manifest. A policy applies to the namespaces listed by name
+// and to the namespaces matching the selector. An empty list of teams,
+// projects or resources allows all of them.
+type CredentialPolicySpec struct {
+	Namespaces        []string              `json:"namespaces,omitempty"`
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	Teams             []string              `json:"teams,omitempty"`
+	Projects          []string              `json:"projects,omitempty"`
+	Resources         []string              `json:"resources,omitempty"`
+}
+
+// CredentialRequest describes the credentials a Project or Resource wants to
+// load. Empty values mean the request isn't scoped, which is only allowed by
+// policies that don't restrict that field.
+type CredentialRequest struct {
+	Team      string
+	Project   string
+	Resources []string
+}
+
+// AppliesTo returns whether this policy applies to the namespace with the
+// given name and labels.
+func (ps *CredentialPolicySpec) AppliesTo(namespace string, nsLabels map[string]string) (bool, error) {
+	for _, n := range ps.Namespaces {
+		if n == namespace {
+			return true, nil
+		}
+	}
+
+	if ps.NamespaceSelector == nil {
+		return false, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(ps.NamespaceSelector)
+	if err != nil {
+		return false, fmt.Errorf("invalid namespace selector: %s", err)
+	}
+
+	return selector.Matches(labels.Set(nsLabels)), nil
+}
+
+// Allows returns whether this policy allows the given request.
+func (ps *CredentialPolicySpec) Allows(req CredentialRequest) bool {
+	if !allowed(ps.Teams, req.Team) || !allowed(ps.Projects, req.Project) {
+		return false
+	}
+
+	if len(ps.Resources) == 0 {
+		return true
+	}
+
+	// Without a list of resources, all resources in the project are requested
+	// which we can't verify against a restricted set of resources.
+	if len(req.Resources) == 0 {
+		return false
+	}
+
+	for _, r := range req.Resources {
+		if !allowed(ps.Resources, r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CredentialRequest returns the credentials this Project requests.
+func (ps *ProjectSpec) CredentialRequest() CredentialRequest {
+	resources := make([]string, len(ps.Resources))
+	for i, r := range ps.Resources {
+		resources[i] = r.Name
+	}
+
+	return CredentialRequest{
+		Team:      ps.Team,
+		Project:   ps.Name,
+		Resources: resources,
+	}
+}
+
+// CredentialRequest returns the credentials this Resource requests.
+func (rs *ResourceSpec) CredentialRequest() CredentialRequest {
+	return CredentialRequest{
+		Team:      rs.Team,
+		Project:   rs.Project,
+		Resources: []string{rs.Name},
+	}
+}
+
+func allowed(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,128 @@
+package primitives
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCredentialPolicySpec_AppliesTo(t *testing.T) {
+	tcs := []struct {
+		scenario  string
+		spec      CredentialPolicySpec
+		namespace string
+		labels    map[string]string
+		result    bool
+	}{
+		{
+			scenario:  "empty",
+			namespace: "dev",
+			result:    false,
+		},
+		{
+			scenario:  "with a matching name",
+			spec:      CredentialPolicySpec{Namespaces: []string{"dev", "staging"}},
+			namespace: "staging",
+			result:    true,
+		},
+		{
+			scenario:  "with a different name",
+			spec:      CredentialPolicySpec{Namespaces: []string{"dev"}},
+			namespace: "production",
+			result:    false,
+		},
+		{
+			scenario: "with a matching selector",
+			spec: CredentialPolicySpec{
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"env": "production"},
+				},
+			},
+			namespace: "payments",
+			labels:    map[string]string{"env": "production"},
+			result:    true,
+		},
+		{
+			scenario: "with a non matching selector",
+			spec: CredentialPolicySpec{
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"env": "production"},
+				},
+			},
+			namespace: "payments",
+			labels:    map[string]string{"env": "dev"},
+			result:    false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			got, err := tc.spec.AppliesTo(tc.namespace, tc.labels)
+			if err != nil {
+				t.Fatalf("expected no error, got %q", err)
+			}
+			if got != tc.result {
+				t.Fatalf("expected policy to apply to eq %t, got %t", tc.result, got)
+			}
+		})
+	}
+}
+
+func TestCredentialPolicySpec_Allows(t *testing.T) {
+	tcs := []struct {
+		scenario string
+		spec     CredentialPolicySpec
+		req      CredentialRequest
+		result   bool
+	}{
+		{
+			scenario: "without restrictions",
+			req:      CredentialRequest{Project: "production"},
+			result:   true,
+		},
+		{
+			scenario: "with an allowed project",
+			spec:     CredentialPolicySpec{Projects: []string{"dev"}},
+			req:      CredentialRequest{Project: "dev"},
+			result:   true,
+		},
+		{
+			scenario: "with a denied project",
+			spec:     CredentialPolicySpec{Projects: []string{"dev"}},
+			req:      CredentialRequest{Project: "production"},
+			result:   false,
+		},
+		{
+			scenario: "with an unscoped team",
+			spec:     CredentialPolicySpec{Teams: []string{"manifold"}},
+			req:      CredentialRequest{Project: "dev"},
+			result:   false,
+		},
+		{
+			scenario: "with allowed resources",
+			spec:     CredentialPolicySpec{Resources: []string{"db", "cache"}},
+			req:      CredentialRequest{Resources: []string{"db"}},
+			result:   true,
+		},
+		{
+			scenario: "with a denied resource",
+			spec:     CredentialPolicySpec{Resources: []string{"db"}},
+			req:      CredentialRequest{Resources: []string{"db", "cache"}},
+			result:   false,
+		},
+		{
+			scenario: "with all resources of a project",
+			spec:     CredentialPolicySpec{Resources: []string{"db"}},
+			req:      CredentialRequest{Project: "dev"},
+			result:   false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			if got := tc.spec.Allows(tc.req); got != tc.result {
+				t.Fatalf("expected policy to allow eq %t, got %t", tc.result, got)
+			}
+		})
+	}
+}
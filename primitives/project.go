@@ -9,11 +9,14 @@ import (
 )
 
 // Project is the manifest representation of a manifold.co Project CRD.
+// +genclient
+// +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 type Project struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
 	Spec              *ProjectSpec `json:"spec"`
+	Status            Status       `json:"status,omitempty"`
 }
 
 // ProjectList represents a list of available ProjectConfigurations in the
@@ -28,10 +31,13 @@ type ProjectList struct {
 // ProjectSpec is the specification that is required to build a valid Project
 // manifest.
 type ProjectSpec struct {
-	Name      string          `json:"project,name"`
-	Team      string          `json:"team,omitempty"`
-	Type      string          `json:"type,omitempty"`
-	Resources []*ResourceSpec `json:"resources,omitempty"`
+	Name           string          `json:"project,name"`
+	Team           string          `json:"team,omitempty"`
+	Account        string          `json:"account,omitempty"`
+	Type           string          `json:"type,omitempty"`
+	WriteStrategy  string          `json:"writeStrategy,omitempty"`
+	DeletionPolicy string          `json:"deletionPolicy,omitempty"`
+	Resources      []*ResourceSpec `json:"resources,omitempty"`
 }
 
 // SecretType returns the type of secret that should be generated for this spec.
@@ -46,6 +52,12 @@ func (ps *ProjectSpec) SecretType() v1.SecretType {
 	return t
 }
 
+// MergeStrategy reports whether the secret of this spec is merged with the keys
+// of the existing secret instead of replacing them.
+func (ps *ProjectSpec) MergeStrategy() bool {
+	return ps.WriteStrategy == WriteStrategyMerge
+}
+
 // ManifoldPrimitive converts the ProjectSpec to a manifold project integration
 // primitive.
 func (ps *ProjectSpec) ManifoldPrimitive() *primitives.Project {
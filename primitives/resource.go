@@ -7,11 +7,14 @@ import (
 )
 
 // Resource is the manifest representation of a manifold.co Resource CRD.
+// +genclient
+// +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 type Resource struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
 	Spec              *ResourceSpec `json:"spec"`
+	Status            Status        `json:"status,omitempty"`
 }
 
 // ResourceList represents a list of available ResourceConfigurations in the
@@ -26,10 +29,14 @@ type ResourceList struct {
 // ResourceSpec is the specification that is required to build a valid Resource
 // manifest.
 type ResourceSpec struct {
-	Name        string            `json:"resource,name"`
-	Team        string            `json:"team,omitempty"`
-	Type        string            `json:"type,omitempty"`
-	Credentials []*CredentialSpec `json:"credentials,omitempty"`
+	Name           string            `json:"resource,name"`
+	Project        string            `json:"project,omitempty"`
+	Team           string            `json:"team,omitempty"`
+	Account        string            `json:"account,omitempty"`
+	Type           string            `json:"type,omitempty"`
+	WriteStrategy  string            `json:"writeStrategy,omitempty"`
+	DeletionPolicy string            `json:"deletionPolicy,omitempty"`
+	Credentials    []*CredentialSpec `json:"credentials,omitempty"`
 }
 
 // SecretType returns the type of secret that should be generated for this spec.
@@ -44,6 +51,24 @@ func (rs *ResourceSpec) SecretType() v1.SecretType {
 	return t
 }
 
+// MergeStrategy reports whether the secret of this spec is merged with the keys
+// of the existing secret instead of replacing them.
+func (rs *ResourceSpec) MergeStrategy() bool {
+	return rs.WriteStrategy == WriteStrategyMerge
+}
+
+// ProjectScope returns the project label this resource should be looked up in.
+// A nil value means the lookup isn't scoped to a project. Resources that are
+// nested within a Project always use the Project's label instead.
+func (rs *ResourceSpec) ProjectScope() *string {
+	if rs.Project == "" {
+		return nil
+	}
+
+	project := rs.Project
+	return &project
+}
+
 // ManifoldPrimitive converts the ResourceSpec to a manifold project integration
 // primitive.
 func (rs *ResourceSpec) ManifoldPrimitive() *primitives.Resource {
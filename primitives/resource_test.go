@@ -0,0 +1,20 @@
+package primitives
+
+import "testing"
+
+func TestResourceSpec_ProjectScope(t *testing.T) {
+	t.Run("without a project", func(t *testing.T) {
+		rs := ResourceSpec{Name: "custom-resource1"}
+		if p := rs.ProjectScope(); p != nil {
+			t.Fatalf("expected no project scope, got %q", *p)
+		}
+	})
+
+	t.Run("with a project", func(t *testing.T) {
+		rs := ResourceSpec{Name: "custom-resource1", Project: "manifold-terraform"}
+		p := rs.ProjectScope()
+		if p == nil || *p != "manifold-terraform" {
+			t.Fatalf("expected project scope to eq %q, got %v", "manifold-terraform", p)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package primitives
+
+import (
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionType is the type of a condition that is reported in the status of a
+// Project or Resource.
+type ConditionType string
+
+// The condition types the controller reports.
+const (
+	// ConditionReady is set when the secret of the object has been synced.
+	ConditionReady ConditionType = "Ready"
+
+	// ConditionPolicyDenied is set when a CredentialPolicy doesn't allow the
+	// namespace of the object to load the requested credentials.
+	ConditionPolicyDenied ConditionType = "PolicyDenied"
+
+	// ConditionServedFromCache is set when the credential source couldn't be
+	// reached and the secret was written from the last known good snapshot.
+	ConditionServedFromCache ConditionType = "ServedFromCache"
+
+	// ConditionSecretConflict is set when a secret with the name of the object
+	// already exists and the controller isn't allowed to take it over.
+	ConditionSecretConflict ConditionType = "SecretConflict"
+
+	// ConditionPaused is set while reconciling the object is paused with the
+	// paused annotation.
+	ConditionPaused ConditionType = "Paused"
+
+	// ConditionInvalidSpec is set when the spec has a value the controller
+	// doesn't support, such as an unknown deletion policy.
+	ConditionInvalidSpec ConditionType = "InvalidSpec"
+)
+
+// Condition describes the state of a Project or Resource at a certain point.
+type Condition struct {
+	Type               ConditionType      `json:"type"`
+	Status             v1.ConditionStatus `json:"status"`
+	Reason             string             `json:"reason,omitempty"`
+	Message            string             `json:"message,omitempty"`
+	LastTransitionTime metav1.Time        `json:"lastTransitionTime,omitempty"`
+}
+
+// Status represents the observed state of a Project or Resource.
+type Status struct {
+	Conditions []Condition `json:"conditions,omitempty"`
+
+	// RefreshedAt is the value of the refresh-at annotation of the last
+	// forced refresh that succeeded.
+	RefreshedAt string `json:"refreshedAt,omitempty"`
+}
+
+// Condition returns the condition of the given type, or nil if it isn't set.
+func (s *Status) Condition(t ConditionType) *Condition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == t {
+			return &s.Conditions[i]
+		}
+	}
+
+	return nil
+}
+
+// SetCondition adds or updates the given condition and reports whether the
+// status changed. The transition time is only updated when the condition's
+// status changes.
+func (s *Status) SetCondition(c Condition) bool {
+	existing := s.Condition(c.Type)
+	if existing == nil {
+		if c.LastTransitionTime.IsZero() {
+			c.LastTransitionTime = metav1.Now()
+		}
+		s.Conditions = append(s.Conditions, c)
+		return true
+	}
+
+	if existing.Status == c.Status && existing.Reason == c.Reason && existing.Message == c.Message {
+		return false
+	}
+
+	if existing.Status == c.Status {
+		c.LastTransitionTime = existing.LastTransitionTime
+	} else if c.LastTransitionTime.IsZero() {
+		c.LastTransitionTime = metav1.Now()
+	}
+	*existing = c
+	return true
+}
+
+// RemoveCondition removes the condition of the given type and reports whether
+// the status changed.
+func (s *Status) RemoveCondition(t ConditionType) bool {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == t {
+			s.Conditions = append(s.Conditions[:i], s.Conditions[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
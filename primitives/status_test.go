@@ -0,0 +1,63 @@
+package primitives
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStatus_SetCondition(t *testing.T) {
+	then := metav1.NewTime(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	later := metav1.NewTime(then.Add(time.Hour))
+
+	tcs := []struct {
+		scenario  string
+		condition Condition
+		changed   bool
+		reason    string
+		time      metav1.Time
+	}{
+		{
+			scenario:  "keeps an identical condition",
+			condition: Condition{Type: ConditionPolicyDenied, Status: v1.ConditionTrue, Reason: "NotAllowed"},
+			reason:    "NotAllowed",
+			time:      then,
+		},
+		{
+			scenario:  "keeps the transition time when only the reason changes",
+			condition: Condition{Type: ConditionPolicyDenied, Status: v1.ConditionTrue, Reason: "TeamMismatch", LastTransitionTime: later},
+			changed:   true,
+			reason:    "TeamMismatch",
+			time:      then,
+		},
+		{
+			scenario:  "updates the transition time when the status changes",
+			condition: Condition{Type: ConditionPolicyDenied, Status: v1.ConditionFalse, Reason: "NotAllowed", LastTransitionTime: later},
+			changed:   true,
+			reason:    "NotAllowed",
+			time:      later,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			s := &Status{Conditions: []Condition{
+				{Type: ConditionPolicyDenied, Status: v1.ConditionTrue, Reason: "NotAllowed", LastTransitionTime: then},
+			}}
+
+			if changed := s.SetCondition(tc.condition); changed != tc.changed {
+				t.Errorf("expected changed to eq %t, got %t", tc.changed, changed)
+			}
+
+			c := s.Condition(ConditionPolicyDenied)
+			if c.Reason != tc.reason {
+				t.Errorf("expected the reason to eq %q, got %q", tc.reason, c.Reason)
+			}
+			if !c.LastTransitionTime.Equal(&tc.time) {
+				t.Errorf("expected the transition time to eq %s, got %s", tc.time, c.LastTransitionTime)
+			}
+		})
+	}
+}
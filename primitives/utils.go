@@ -6,10 +6,54 @@ import (
 	"k8s.io/api/core/v1"
 )
 
+// The strategies to write the data of a secret.
+const (
+	// WriteStrategyReplace replaces all data of the secret. It's the default.
+	WriteStrategyReplace = "replace"
+
+	// WriteStrategyMerge only adds, updates and removes the keys the
+	// controller manages and leaves all other keys of the secret alone.
+	WriteStrategyMerge = "merge"
+)
+
+func writeStrategy(s string) error {
+	switch s {
+	case WriteStrategyReplace, WriteStrategyMerge, "":
+		return nil
+	}
+
+	return fmt.Errorf("Write strategy '%s' not supported", s)
+}
+
+// The policies for the secret of a Project or Resource which is deleted.
+const (
+	// DeletionPolicyDelete deletes the secret.
+	DeletionPolicyDelete = "Delete"
+
+	// DeletionPolicyRetain keeps the secret, removes its owner reference and
+	// marks it with the orphaned-from annotation, so it can be taken over by a
+	// new object with the same kind and name.
+	DeletionPolicyRetain = "Retain"
+
+	// DeletionPolicyOrphan keeps the secret and only removes its owner
+	// reference.
+	DeletionPolicyOrphan = "Orphan"
+)
+
+// ValidateDeletionPolicy returns an error if the deletion policy isn't
+// supported. The empty string uses the default policy of the controller.
+func ValidateDeletionPolicy(p string) error {
+	switch p {
+	case DeletionPolicyDelete, DeletionPolicyRetain, DeletionPolicyOrphan, "":
+		return nil
+	}
+
+	return fmt.Errorf("Deletion policy '%s' not supported", p)
+}
+
 func secretType(t string) (v1.SecretType, error) {
 	switch t {
-	case "opaque":
-	case "":
+	case "opaque", "":
 		return v1.SecretTypeOpaque, nil
 	case "docker-registry":
 		return v1.SecretTypeDockercfg, nil
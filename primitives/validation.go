@@ -0,0 +1,121 @@
+package primitives
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// secretKeyRegexp matches the characters Kubernetes allows in secret keys.
+var secretKeyRegexp = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+// ValidationError is a problem with a Project or Resource spec.
+type ValidationError struct {
+	// Field is the path of the field with the problem, for example
+	// spec.credentials[1].encoding.
+	Field string
+
+	// Value is the value of the field, if it's set.
+	Value string
+
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Validate checks the spec for problems the controller would run into when
+// writing its secret.
+func (ps *ProjectSpec) Validate() []*ValidationError {
+	var errs []*ValidationError
+	if ps.Name == "" {
+		errs = append(errs, &ValidationError{Field: "spec.project", Message: "the project label is required"})
+	}
+
+	if _, err := secretType(ps.Type); err != nil {
+		errs = append(errs, &ValidationError{Field: "spec.type", Value: ps.Type, Message: err.Error()})
+	}
+
+	if err := writeStrategy(ps.WriteStrategy); err != nil {
+		errs = append(errs, &ValidationError{Field: "spec.writeStrategy", Value: ps.WriteStrategy, Message: err.Error()})
+	}
+
+	if err := ValidateDeletionPolicy(ps.DeletionPolicy); err != nil {
+		errs = append(errs, &ValidationError{Field: "spec.deletionPolicy", Value: ps.DeletionPolicy, Message: err.Error()})
+	}
+
+	keys := map[string]string{}
+	for i, r := range ps.Resources {
+		field := fmt.Sprintf("spec.resources[%d]", i)
+		if r == nil {
+			errs = append(errs, &ValidationError{Field: field, Message: "the resource is empty"})
+			continue
+		}
+
+		if r.Name == "" {
+			errs = append(errs, &ValidationError{Field: field + ".resource", Message: "the resource label is required"})
+		}
+
+		errs = append(errs, validateCredentials(field, r.Credentials, keys)...)
+	}
+
+	return errs
+}
+
+// Validate checks the spec for problems the controller would run into when
+// writing its secret.
+func (rs *ResourceSpec) Validate() []*ValidationError {
+	var errs []*ValidationError
+	if rs.Name == "" {
+		errs = append(errs, &ValidationError{Field: "spec.resource", Message: "the resource label is required"})
+	}
+
+	if _, err := secretType(rs.Type); err != nil {
+		errs = append(errs, &ValidationError{Field: "spec.type", Value: rs.Type, Message: err.Error()})
+	}
+
+	if err := writeStrategy(rs.WriteStrategy); err != nil {
+		errs = append(errs, &ValidationError{Field: "spec.writeStrategy", Value: rs.WriteStrategy, Message: err.Error()})
+	}
+
+	if err := ValidateDeletionPolicy(rs.DeletionPolicy); err != nil {
+		errs = append(errs, &ValidationError{Field: "spec.deletionPolicy", Value: rs.DeletionPolicy, Message: err.Error()})
+	}
+
+	return append(errs, validateCredentials("spec", rs.Credentials, map[string]string{})...)
+}
+
+// validateCredentials checks the credentials of a resource. The keys map
+// tracks which field set each secret key, to detect collisions across the
+// resources of a project.
+func validateCredentials(parent string, creds []*CredentialSpec, keys map[string]string) []*ValidationError {
+	var errs []*ValidationError
+	for i, c := range creds {
+		field := fmt.Sprintf("%s.credentials[%d]", parent, i)
+		if c == nil || c.Key == "" {
+			errs = append(errs, &ValidationError{Field: field + ".key", Message: "the credential key is required"})
+			continue
+		}
+
+		if c.Encoding != "" && c.Encoding != EncodingBase64 {
+			errs = append(errs, &ValidationError{Field: field + ".encoding", Value: c.Encoding, Message: fmt.Sprintf("encoding '%s' is not supported", c.Encoding)})
+		}
+
+		key, keyField := c.Key, field+".key"
+		if c.Name != "" {
+			key, keyField = c.Name, field+".name"
+		}
+
+		if key == "." || key == ".." || !secretKeyRegexp.MatchString(key) {
+			errs = append(errs, &ValidationError{Field: keyField, Value: key, Message: fmt.Sprintf("'%s' is not a valid secret key, only letters, digits, '-', '_' and '.' are allowed", key)})
+		}
+
+		if other, ok := keys[key]; ok {
+			errs = append(errs, &ValidationError{Field: keyField, Value: key, Message: fmt.Sprintf("secret key '%s' is already set by %s", key, other)})
+			continue
+		}
+		keys[key] = keyField
+	}
+
+	return errs
+}
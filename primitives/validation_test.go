@@ -0,0 +1,111 @@
+package primitives
+
+import "testing"
+
+func TestResourceSpec_Validate(t *testing.T) {
+	tcs := []struct {
+		scenario string
+		spec     ResourceSpec
+		fields   []string
+	}{
+		{
+			scenario: "with a valid spec",
+			spec: ResourceSpec{
+				Name: "custom-resource1",
+				Type: "opaque",
+				Credentials: []*CredentialSpec{
+					{Key: "TOKEN_ID"},
+					{Key: "TOKEN_SECRET", Name: "alias-name", Encoding: "base64"},
+				},
+			},
+		},
+		{
+			scenario: "without a resource label",
+			spec:     ResourceSpec{},
+			fields:   []string{"spec.resource"},
+		},
+		{
+			scenario: "with an unknown secret type",
+			spec:     ResourceSpec{Name: "custom-resource1", Type: "tls"},
+			fields:   []string{"spec.type"},
+		},
+		{
+			scenario: "with an unknown deletion policy",
+			spec:     ResourceSpec{Name: "custom-resource1", DeletionPolicy: "Keep"},
+			fields:   []string{"spec.deletionPolicy"},
+		},
+		{
+			scenario: "with an unknown write strategy",
+			spec:     ResourceSpec{Name: "custom-resource1", WriteStrategy: "append"},
+			fields:   []string{"spec.writeStrategy"},
+		},
+		{
+			scenario: "with an unsupported encoding",
+			spec: ResourceSpec{
+				Name:        "custom-resource1",
+				Credentials: []*CredentialSpec{{Key: "TOKEN_ID", Encoding: "base32"}},
+			},
+			fields: []string{"spec.credentials[0].encoding"},
+		},
+		{
+			scenario: "with an invalid secret key",
+			spec: ResourceSpec{
+				Name:        "custom-resource1",
+				Credentials: []*CredentialSpec{{Key: "TOKEN_ID", Name: "token id"}},
+			},
+			fields: []string{"spec.credentials[0].name"},
+		},
+		{
+			scenario: "with a key collision",
+			spec: ResourceSpec{
+				Name: "custom-resource1",
+				Credentials: []*CredentialSpec{
+					{Key: "TOKEN_ID"},
+					{Key: "TOKEN_SECRET", Name: "TOKEN_ID"},
+				},
+			},
+			fields: []string{"spec.credentials[1].name"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			errs := tc.spec.Validate()
+			if len(errs) != len(tc.fields) {
+				t.Fatalf("expected %d errors, got %v", len(tc.fields), errs)
+			}
+
+			for i, field := range tc.fields {
+				if errs[i].Field != field {
+					t.Errorf("expected field to eq %q, got %q", field, errs[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestProjectSpec_Validate(t *testing.T) {
+	t.Run("with a key collision across resources", func(t *testing.T) {
+		ps := ProjectSpec{
+			Name: "manifold-terraform",
+			Resources: []*ResourceSpec{
+				{Name: "custom-resource1", Credentials: []*CredentialSpec{{Key: "TOKEN_ID"}}},
+				{Name: "custom-resource2", Credentials: []*CredentialSpec{{Key: "TOKEN_ID"}}},
+			},
+		}
+
+		errs := ps.Validate()
+		if len(errs) != 1 || errs[0].Field != "spec.resources[1].credentials[0].key" {
+			t.Fatalf("expected a collision on the second resource, got %v", errs)
+		}
+	})
+
+	t.Run("without labels", func(t *testing.T) {
+		ps := ProjectSpec{Resources: []*ResourceSpec{{}}}
+
+		errs := ps.Validate()
+		if len(errs) != 2 || errs[0].Field != "spec.project" || errs[1].Field != "spec.resources[0].resource" {
+			t.Fatalf("expected the missing labels to be reported, got %v", errs)
+		}
+	})
+}
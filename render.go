@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ghodss/yaml"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/manifoldco/kubernetes-credentials/logging"
+	"github.com/manifoldco/kubernetes-credentials/manifest"
+)
+
+// runRender prints the secrets the controller would write for the Projects
+// and Resources in the given manifests.
+func runRender(args []string) int {
+	fs := newFlagSet("render", "<file or directory>...", "Prints the secrets the controller would write for the Projects and Resources in the given manifests.\nManifoldAccounts aren't resolved: objects which reference one are rendered with the default credential source.")
+	credentialsFile := fs.String("credentials-file", "", "Load credentials from this local file or directory instead of the Manifold API.")
+	showValues := fs.Bool("show-values", false, "Show the credential values instead of masking them.")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	setupCommandLogging()
+	ctx := context.Background()
+
+	objects, err := manifest.Load(fs.Args()...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	src, err := commandSource(ctx, *credentialsFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	code := 0
+	printed := false
+	for _, obj := range objects {
+		secret, err := desiredSecret(ctx, defaultSources(src), obj)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s:%d: could not render %s '%s': %s\n", obj.File, obj.Line, obj.Kind(), obj.Meta().Name, err)
+			code = 1
+			continue
+		}
+
+		if printed {
+			fmt.Println("---")
+		}
+		printed = true
+
+		if err := printSecret(os.Stdout, secret, *showValues); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	return code
+}
+
+// printSecret writes the secret as a YAML manifest. Unless showValues is set,
+// the values are masked.
+func printSecret(w io.Writer, secret *v1.Secret, showValues bool) error {
+	out := secret.DeepCopy()
+	out.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"}
+
+	// The owner reference needs the UID of the object in the cluster, which
+	// we don't know here.
+	out.OwnerReferences = nil
+
+	if !showValues {
+		out.StringData = make(map[string]string, len(out.Data))
+		for k := range out.Data {
+			out.StringData[k] = logging.Redacted
+		}
+		out.Data = nil
+	}
+
+	bts, err := yaml.Marshal(out)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(bts)
+	return err
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/manifoldco/kubernetes-credentials/logging"
+)
+
+func TestPrintSecret(t *testing.T) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "creds",
+			Namespace:       "default",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Project", Name: "creds"}},
+		},
+		Data: map[string][]byte{"PASSWORD": []byte("prod-pass")},
+		Type: v1.SecretTypeOpaque,
+	}
+
+	tcs := []struct {
+		scenario   string
+		showValues bool
+		data       map[string][]byte
+		stringData map[string]string
+	}{
+		{
+			scenario:   "masks the values",
+			stringData: map[string]string{"PASSWORD": logging.Redacted},
+		},
+		{
+			scenario:   "shows the values",
+			showValues: true,
+			data:       map[string][]byte{"PASSWORD": []byte("prod-pass")},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := printSecret(&buf, secret, tc.showValues); err != nil {
+				t.Fatalf("expected no error, got %q", err)
+			}
+			if !tc.showValues && strings.Contains(buf.String(), "prod-pass") {
+				t.Errorf("expected the value to be masked, got\n%s", buf.String())
+			}
+
+			var out v1.Secret
+			if err := yaml.Unmarshal(buf.Bytes(), &out); err != nil {
+				t.Fatalf("expected a valid manifest, got %q", err)
+			}
+
+			if out.APIVersion != "v1" || out.Kind != "Secret" {
+				t.Errorf("expected a v1 Secret, got %s %s", out.APIVersion, out.Kind)
+			}
+			if len(out.OwnerReferences) != 0 {
+				t.Errorf("expected no owner references, got %v", out.OwnerReferences)
+			}
+			if len(out.Data) != len(tc.data) || string(out.Data["PASSWORD"]) != string(tc.data["PASSWORD"]) {
+				t.Errorf("expected data to eq %q, got %q", tc.data, out.Data)
+			}
+			if len(out.StringData) != len(tc.stringData) || out.StringData["PASSWORD"] != tc.stringData["PASSWORD"] {
+				t.Errorf("expected stringData to eq %v, got %v", tc.stringData, out.StringData)
+			}
+		})
+	}
+
+	if len(secret.OwnerReferences) != 1 || string(secret.Data["PASSWORD"]) != "prod-pass" {
+		t.Error("expected the secret not to be changed")
+	}
+}
@@ -0,0 +1,207 @@
+// Package snapshot keeps an encrypted last-known-good copy of the credentials
+// the controller fetched, so they can still be served when the upstream
+// credential source is unavailable.
+package snapshot
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// KeySize is the required size of the encryption key, for AES-256-GCM.
+const KeySize = 32
+
+// Store persists the encrypted snapshot.
+type Store interface {
+	// Load returns the stored snapshot, or nil if there is none yet.
+	Load() ([]byte, error)
+
+	// Save replaces the stored snapshot.
+	Save([]byte) error
+}
+
+// Entry is the last known good set of flattened credential values for a
+// request.
+type Entry struct {
+	FetchedAt time.Time         `json:"fetched_at"`
+	Values    map[string]string `json:"values"`
+}
+
+// Snapshot is an in-memory set of entries which is periodically written to a
+// Store.
+type Snapshot struct {
+	store        Store
+	aead         cipher.AEAD
+	maxStaleness time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*Entry
+	dirty   bool
+}
+
+// New returns a Snapshot which is encrypted with the given key. Entries older
+// than maxStaleness are never served.
+func New(store Store, key []byte, maxStaleness time.Duration) (*Snapshot, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("snapshot key needs to be %d bytes, got %d", KeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Snapshot{
+		store:        store,
+		aead:         aead,
+		maxStaleness: maxStaleness,
+		entries:      map[string]*Entry{},
+	}, nil
+}
+
+// Key returns the snapshot key for the given parts, which together should
+// identify the credential request.
+func Key(parts ...interface{}) string {
+	bts, err := json.Marshal(parts)
+	if err != nil {
+		// All parts are plain data structures; this can't happen.
+		panic(err)
+	}
+
+	sum := sha256.Sum256(bts)
+	return hex.EncodeToString(sum[:])
+}
+
+// Load reads the snapshot from the store, replacing the entries in memory.
+func (s *Snapshot) Load() error {
+	sealed, err := s.store.Load()
+	if err != nil {
+		return err
+	}
+
+	if sealed == nil {
+		return nil
+	}
+
+	nonceSize := s.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return errors.New("snapshot is corrupt")
+	}
+
+	plain, err := s.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return fmt.Errorf("could not decrypt snapshot: %s", err)
+	}
+
+	entries := map[string]*Entry{}
+	if err := json.Unmarshal(plain, &entries); err != nil {
+		return fmt.Errorf("could not parse snapshot: %s", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = entries
+	s.dirty = false
+	return nil
+}
+
+// Put records the values as the last known good values for the key.
+func (s *Snapshot) Put(key string, values map[string]string) {
+	copied := make(map[string]string, len(values))
+	for k, v := range values {
+		copied[k] = v
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = &Entry{FetchedAt: time.Now(), Values: copied}
+	s.dirty = true
+}
+
+// Get returns the entry for the key, unless it's older than the maximum
+// staleness.
+func (s *Snapshot) Get(key string) (*Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok || time.Since(e.FetchedAt) > s.maxStaleness {
+		return nil, false
+	}
+
+	return e, true
+}
+
+// Flush writes the snapshot to the store if it changed since the last write.
+// Entries that are too stale to be served are dropped.
+func (s *Snapshot) Flush() error {
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+
+	for k, e := range s.entries {
+		if time.Since(e.FetchedAt) > s.maxStaleness {
+			delete(s.entries, k)
+		}
+	}
+
+	plain, err := json.Marshal(s.entries)
+	s.dirty = false
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	if err := s.store.Save(s.aead.Seal(nonce, nonce, plain, nil)); err != nil {
+		s.mu.Lock()
+		s.dirty = true
+		s.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// Run flushes the snapshot at the given interval until the context is
+// cancelled. Call Flush once more when done writing entries.
+func (s *Snapshot) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				log.WithError(err).Error("could not write the credentials snapshot")
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package snapshot
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+type memoryStore struct {
+	data []byte
+}
+
+func (m *memoryStore) Load() ([]byte, error) { return m.data, nil }
+func (m *memoryStore) Save(data []byte) error {
+	m.data = data
+	return nil
+}
+
+func TestSnapshot(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, KeySize)
+
+	t.Run("with a round trip", func(t *testing.T) {
+		store := &memoryStore{}
+		s, err := New(store, key, time.Hour)
+		if err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+
+		s.Put("key", map[string]string{"TOKEN": "secret-value"})
+		if err := s.Flush(); err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+
+		if bytes.Contains(store.data, []byte("secret-value")) {
+			t.Fatal("expected the stored snapshot to be encrypted")
+		}
+
+		loaded, _ := New(store, key, time.Hour)
+		if err := loaded.Load(); err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+
+		e, ok := loaded.Get("key")
+		if !ok {
+			t.Fatal("expected entry to be found")
+		}
+		if v := e.Values["TOKEN"]; v != "secret-value" {
+			t.Fatalf("expected TOKEN to eq %q, got %q", "secret-value", v)
+		}
+	})
+
+	t.Run("with a different key", func(t *testing.T) {
+		store := &memoryStore{}
+		s, _ := New(store, key, time.Hour)
+		s.Put("key", map[string]string{"TOKEN": "secret-value"})
+		if err := s.Flush(); err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+
+		other, _ := New(store, bytes.Repeat([]byte{2}, KeySize), time.Hour)
+		if err := other.Load(); err == nil {
+			t.Fatal("expected an error decrypting with a different key, got none")
+		}
+	})
+
+	t.Run("with a stale entry", func(t *testing.T) {
+		s, _ := New(&memoryStore{}, key, time.Hour)
+		s.Put("key", map[string]string{"TOKEN": "secret-value"})
+		s.entries["key"].FetchedAt = time.Now().Add(-2 * time.Hour)
+
+		if _, ok := s.Get("key"); ok {
+			t.Fatal("expected stale entry not to be served")
+		}
+	})
+
+	t.Run("with an invalid key size", func(t *testing.T) {
+		if _, err := New(&memoryStore{}, []byte("short"), time.Hour); err == nil {
+			t.Fatal("expected an error, got none")
+		}
+	})
+}
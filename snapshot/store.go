@@ -0,0 +1,96 @@
+package snapshot
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// secretKey is the key in the Secret which holds the snapshot.
+const secretKey = "snapshot"
+
+// SecretStore stores the snapshot in a Secret.
+type SecretStore struct {
+	Client    kubernetes.Interface
+	Namespace string
+	Name      string
+}
+
+// Load implements the Store interface.
+func (s *SecretStore) Load() ([]byte, error) {
+	secret, err := s.Client.Core().Secrets(s.Namespace).Get(s.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return secret.Data[secretKey], nil
+}
+
+// Save implements the Store interface.
+func (s *SecretStore) Save(data []byte) error {
+	secrets := s.Client.Core().Secrets(s.Namespace)
+
+	secret, err := secrets.Get(s.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = secrets.Create(&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      s.Name,
+				Namespace: s.Namespace,
+			},
+			Data: map[string][]byte{secretKey: data},
+			Type: v1.SecretTypeOpaque,
+		})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	secret.Data = map[string][]byte{secretKey: data}
+	_, err = secrets.Update(secret)
+	return err
+}
+
+// FileStore stores the snapshot in a file, for example on a persistent volume.
+type FileStore struct {
+	Path string
+}
+
+// Load implements the Store interface.
+func (s *FileStore) Load() ([]byte, error) {
+	bts, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return bts, err
+}
+
+// Save implements the Store interface. The file is replaced atomically so a
+// crash can't leave a partially written snapshot behind.
+func (s *FileStore) Save(data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(s.Path), filepath.Base(s.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.Path)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/manifoldco/kubernetes-credentials/snapshot"
+)
+
+// newSnapshot returns the last known good snapshot configured through the
+// options, or nil when it's disabled.
+func newSnapshot(opts *options, kc kubernetes.Interface) (*snapshot.Snapshot, error) {
+	var store snapshot.Store
+	switch {
+	case opts.snapshotSecret != "" && opts.snapshotFile != "":
+		return nil, fmt.Errorf("only one of --snapshot-secret and --snapshot-file can be set")
+	case opts.snapshotSecret != "":
+		parts := strings.SplitN(opts.snapshotSecret, "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("--snapshot-secret needs to be in the namespace/name format")
+		}
+		store = &snapshot.SecretStore{Client: kc, Namespace: parts[0], Name: parts[1]}
+	case opts.snapshotFile != "":
+		store = &snapshot.FileStore{Path: opts.snapshotFile}
+	default:
+		return nil, nil
+	}
+
+	if opts.snapshotKeyFile == "" {
+		return nil, fmt.Errorf("--snapshot-key-file is required to encrypt the snapshot")
+	}
+
+	encoded, err := ioutil.ReadFile(opts.snapshotKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return nil, fmt.Errorf("snapshot key needs to be base64 encoded: %s", err)
+	}
+
+	snap, err := snapshot.New(store, key, opts.snapshotMaxStaleness)
+	if err != nil {
+		return nil, err
+	}
+
+	// A snapshot we can't read, for example after rotating the key, shouldn't
+	// keep the controller from starting. It will be replaced on the next
+	// flush.
+	if err := snap.Load(); err != nil {
+		log.WithError(err).Warn("could not load the credentials snapshot, starting with an empty one")
+	}
+
+	return snap, nil
+}
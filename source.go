@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/manifoldco/kubernetes-credentials/controller"
+	"github.com/manifoldco/kubernetes-credentials/filesource"
+)
+
+// defaultSource returns the credential source for Projects and Resources that
+// don't reference a ManifoldAccount and the team it's bound to. It's nil when
+// none is configured. When the Manifold API token is read from a file, the
+// returned reloader keeps the source up to date and needs to be run.
+func defaultSource(ctx context.Context, opts *options) (controller.CredentialSource, string, *tokenReloader, error) {
+	team := os.Getenv("MANIFOLD_TEAM")
+
+	if opts.credentialsFile != "" {
+		src, err := filesource.Load(opts.credentialsFile, team)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		log.WithField("path", opts.credentialsFile).Info("Loading credentials from a local file")
+		return src, team, nil, nil
+	}
+
+	if tokenFile := os.Getenv("MANIFOLD_API_TOKEN_FILE"); tokenFile != "" {
+		reloader := &tokenReloader{
+			tokenFile: tokenFile,
+			teamFile:  os.Getenv("MANIFOLD_TEAM_FILE"),
+			interval:  opts.tokenReloadInterval,
+		}
+
+		wrapper, err := reloader.load(ctx)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		return wrapper, string(reloader.team), reloader, nil
+	}
+
+	if token := os.Getenv("MANIFOLD_API_TOKEN"); token != "" {
+		wrapper, err := newManifoldClient(token, team)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		return wrapper, team, nil, nil
+	}
+
+	return nil, "", nil, nil
+}
@@ -0,0 +1,160 @@
+package throttle
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned for calls which are rejected because the circuit
+// breaker is open.
+var ErrOpen = errors.New("circuit breaker is open, not calling the Manifold API")
+
+// State is the state of a circuit breaker.
+type State int
+
+// The states of a circuit breaker.
+const (
+	// StateClosed allows all calls.
+	StateClosed State = iota
+
+	// StateOpen rejects all calls until the probe interval has passed.
+	StateOpen
+
+	// StateHalfOpen allows a single probe call, which closes the breaker
+	// when it succeeds and opens it again when it fails.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota
+	outcomeFailure
+	outcomeIgnored
+)
+
+// Breaker is a circuit breaker. A nil Breaker allows every call.
+type Breaker struct {
+	threshold     int
+	probeInterval time.Duration
+
+	// OnStateChange is called whenever the state changes. It's called with
+	// the breaker locked, so it can't call back into the breaker. Set it
+	// before the breaker is used.
+	OnStateChange func(from, to State)
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	openedAt  time.Time
+	nextProbe time.Time
+
+	now func() time.Time
+}
+
+// NewBreaker returns a Breaker which opens after threshold consecutive
+// failures and then allows a probe call every probeInterval. The breaker
+// never opens when threshold is zero or less.
+func NewBreaker(threshold int, probeInterval time.Duration) *Breaker {
+	return &Breaker{
+		threshold:     threshold,
+		probeInterval: probeInterval,
+		now:           time.Now,
+	}
+}
+
+// State returns the current state of the breaker and, unless it's closed,
+// when it opened.
+func (b *Breaker) State() (State, time.Time) {
+	if b == nil {
+		return StateClosed, time.Time{}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state, b.openedAt
+}
+
+// Allow returns ErrOpen when a call isn't allowed. Once the probe interval has
+// passed, a single call is let through to probe the API.
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if b.now().Before(b.nextProbe) {
+			return ErrOpen
+		}
+
+		b.setState(StateHalfOpen)
+		return nil
+	case StateHalfOpen:
+		return ErrOpen
+	default:
+		return nil
+	}
+}
+
+func (b *Breaker) record(o outcome) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch o {
+	case outcomeSuccess:
+		b.failures = 0
+		if b.state != StateClosed {
+			b.openedAt = time.Time{}
+			b.setState(StateClosed)
+		}
+	case outcomeFailure:
+		b.failures++
+		switch {
+		case b.state == StateHalfOpen:
+			b.nextProbe = b.now().Add(b.probeInterval)
+			b.setState(StateOpen)
+		case b.state == StateClosed && b.threshold > 0 && b.failures >= b.threshold:
+			b.openedAt = b.now()
+			b.nextProbe = b.openedAt.Add(b.probeInterval)
+			b.setState(StateOpen)
+		}
+	case outcomeIgnored:
+		// The probe didn't tell us anything, so the next call probes again
+		// right away.
+		if b.state == StateHalfOpen {
+			b.setState(StateOpen)
+		}
+	}
+}
+
+func (b *Breaker) setState(s State) {
+	from := b.state
+	b.state = s
+
+	if b.OnStateChange != nil {
+		b.OnStateChange(from, s)
+	}
+}
@@ -0,0 +1,110 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket rate limiter. A nil Limiter allows every call.
+type Limiter struct {
+	qps   float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	paused time.Time
+
+	now func() time.Time
+}
+
+// NewLimiter returns a Limiter which allows qps calls per second on average
+// and bursts of up to burst calls. The rate is unlimited when qps is zero or
+// less, but the limiter can still be paused.
+func NewLimiter(qps float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &Limiter{
+		qps:    qps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// Wait blocks until a call is allowed or the context is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Pause holds all calls for the given duration, for example because the API
+// responded with a Retry-After.
+func (l *Limiter) Pause(d time.Duration) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := l.now().Add(d); until.After(l.paused) {
+		l.paused = until
+	}
+}
+
+// reserve takes a token and returns how long the caller has to wait before
+// using it.
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	var delay time.Duration
+	if l.paused.After(now) {
+		delay = l.paused.Sub(now)
+	}
+
+	if l.qps <= 0 {
+		return delay
+	}
+
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.qps
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+
+	// Tokens can go negative, which makes the following callers queue up
+	// behind this one.
+	l.tokens--
+	if l.tokens < 0 {
+		if wait := time.Duration(-l.tokens / l.qps * float64(time.Second)); wait > delay {
+			delay = wait
+		}
+	}
+
+	return delay
+}
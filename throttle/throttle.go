@@ -0,0 +1,191 @@
+// Package throttle protects an upstream API from the controller during
+// outages. It combines a client-side token bucket rate limiter with a circuit
+// breaker which stops requests after consecutive failures and probes the API
+// periodically until it recovers. Both guard every HTTP request made through
+// the RoundTripper returned by Throttle.Transport.
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRetryAfter is how long requests are paused after a rate limited
+// response which doesn't say when to retry.
+const DefaultRetryAfter = 30 * time.Second
+
+// statusCoder is implemented by errors that carry the HTTP status code of the
+// failed request.
+type statusCoder interface {
+	StatusCode() int
+}
+
+// StatusError is returned by the Transport for failed responses which don't
+// have a JSON body, like the error pages of a proxy or load balancer in front
+// of the API. API clients that decode the body of every failed response would
+// fail with a decode error instead, which hides the status code.
+type StatusError struct {
+	Code   int
+	Status string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected response without a JSON body: %s", e.Status)
+}
+
+// StatusCode returns the HTTP status code of the failed response.
+func (e *StatusError) StatusCode() int {
+	return e.Code
+}
+
+// Throttle guards requests to an upstream API with a Limiter and a Breaker.
+// Either can be nil to disable it.
+type Throttle struct {
+	Limiter *Limiter
+	Breaker *Breaker
+
+	// RetryAfter is used for rate limited responses without a Retry-After.
+	// DefaultRetryAfter is used when it's zero.
+	RetryAfter time.Duration
+}
+
+// Wait blocks until a request is allowed. It returns ErrOpen without waiting
+// when the circuit breaker is open, or the context error when the context is
+// done first. Every successful Wait has to be followed by a call to done.
+func (t *Throttle) Wait(ctx context.Context) error {
+	if err := t.Breaker.Allow(); err != nil {
+		return err
+	}
+
+	if err := t.Limiter.Wait(ctx); err != nil {
+		t.Breaker.record(outcomeIgnored)
+		return err
+	}
+
+	return nil
+}
+
+// Transport returns a RoundTripper which makes every request through base
+// wait for the throttle, so a high-level call which makes several requests
+// takes a token from the limiter for each of them. Failed responses without a
+// JSON body are returned as a *StatusError.
+func (t *Throttle) Transport(base http.RoundTripper) http.RoundTripper {
+	return &transport{t: t, base: base}
+}
+
+type transport struct {
+	t    *Throttle
+	base http.RoundTripper
+}
+
+func (rt *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := rt.t.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+
+	resp, err := rt.base.RoundTrip(req)
+	rt.t.done(req.Context(), resp, err)
+	if err == nil && resp.StatusCode >= 400 && !isJSON(resp) {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		return nil, &StatusError{Code: resp.StatusCode, Status: resp.Status}
+	}
+
+	return resp, err
+}
+
+// isJSON reports whether the response has a JSON body.
+func isJSON(resp *http.Response) bool {
+	typ, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	return typ == "application/json" || strings.HasSuffix(typ, "+json")
+}
+
+// done records the result of a request allowed by Wait.
+//
+// Transport errors and 5xx responses count as failures towards the circuit
+// breaker. Rate limited responses pause the limiter for their Retry-After
+// instead. Any other response means the API is up.
+func (t *Throttle) done(ctx context.Context, resp *http.Response, err error) {
+	switch {
+	case err != nil && ctx.Err() != nil:
+		t.Breaker.record(outcomeIgnored)
+	case err != nil, resp.StatusCode >= 500:
+		t.Breaker.record(outcomeFailure)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		t.Limiter.Pause(t.retryAfter(resp.Header.Get("Retry-After")))
+		t.Breaker.record(outcomeIgnored)
+	default:
+		t.Breaker.record(outcomeSuccess)
+	}
+}
+
+// retryAfter parses a Retry-After header, which is either a number of seconds
+// or a date.
+func (t *Throttle) retryAfter(header string) time.Duration {
+	if secs, err := strconv.Atoi(header); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+
+	if t.RetryAfter > 0 {
+		return t.RetryAfter
+	}
+
+	return DefaultRetryAfter
+}
+
+// IsOpen reports whether the request failed because the circuit breaker is
+// open, in which case it never reached the API. HTTP clients wrap the error
+// of the Transport in a url.Error.
+func IsOpen(err error) bool {
+	if e, ok := err.(*url.Error); ok {
+		err = e.Err
+	}
+
+	return err == ErrOpen
+}
+
+// Transient reports whether the error means the API is unavailable rather
+// than that the call itself is wrong: transport errors, 5xx and rate limited
+// responses, and calls stopped by an open circuit breaker. Retrying a call
+// which failed with any other error gives the same result. A StatusError of
+// the Transport is classified by its status code, even though HTTP clients
+// wrap it in a url.Error like transport errors.
+func Transient(err error) bool {
+	if IsOpen(err) {
+		return true
+	}
+
+	if e, ok := err.(*url.Error); ok {
+		if _, ok := e.Err.(statusCoder); ok {
+			err = e.Err
+		}
+	}
+
+	switch e := err.(type) {
+	case *url.Error, net.Error:
+		return true
+	case statusCoder:
+		return e.StatusCode() >= 500 || e.StatusCode() == 429
+	default:
+		return false
+	}
+}
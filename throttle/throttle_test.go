@@ -0,0 +1,399 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type statusError struct {
+	code int
+}
+
+func (e statusError) Error() string   { return "request failed" }
+func (e statusError) StatusCode() int { return e.code }
+
+type rtFunc func(*http.Request) (*http.Response, error)
+
+func (f rtFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// respond returns a RoundTripper which answers every request with the given
+// status code and Retry-After and a JSON body, or fails with a transport error
+// when the code is zero. It counts the requests it received.
+func respond(code int, retryAfter string, requests *int) http.RoundTripper {
+	return rtFunc(func(*http.Request) (*http.Response, error) {
+		*requests++
+		if code == 0 {
+			return nil, errors.New("connection refused")
+		}
+
+		resp := &http.Response{
+			StatusCode: code,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader("{}")),
+		}
+		if retryAfter != "" {
+			resp.Header.Set("Retry-After", retryAfter)
+		}
+		return resp, nil
+	})
+}
+
+// finish records the result of a request allowed by Wait, which was answered
+// with the given status code or failed with a transport error when the code is
+// zero.
+func finish(th *Throttle, code int) {
+	if code == 0 {
+		th.done(context.Background(), nil, errors.New("connection refused"))
+		return
+	}
+
+	th.done(context.Background(), &http.Response{StatusCode: code, Header: http.Header{}}, nil)
+}
+
+// clock is a fake time source which only moves when told to.
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestBreaker(c *clock) *Breaker {
+	b := NewBreaker(3, time.Minute)
+	b.now = c.now
+	return b
+}
+
+func TestBreaker(t *testing.T) {
+	t.Run("opens after consecutive failures", func(t *testing.T) {
+		c := &clock{t: time.Now()}
+		b := newTestBreaker(c)
+		th := &Throttle{Breaker: b}
+
+		for i := 0; i < 3; i++ {
+			if err := th.Wait(context.Background()); err != nil {
+				t.Fatalf("expected call %d to be allowed, got %s", i, err)
+			}
+			finish(th, 0)
+		}
+
+		if err := th.Wait(context.Background()); err != ErrOpen {
+			t.Errorf("expected err to eq %q, got %v", ErrOpen, err)
+		}
+
+		if state, _ := b.State(); state != StateOpen {
+			t.Errorf("expected state to eq %q, got %q", StateOpen, state)
+		}
+	})
+
+	t.Run("resets the failure count on success", func(t *testing.T) {
+		c := &clock{t: time.Now()}
+		th := &Throttle{Breaker: newTestBreaker(c)}
+
+		for _, code := range []int{503, 500, 200, 502, 404, 500} {
+			if err := th.Wait(context.Background()); err != nil {
+				t.Fatalf("expected call to be allowed, got %s", err)
+			}
+			finish(th, code)
+		}
+
+		if state, _ := th.Breaker.State(); state != StateClosed {
+			t.Errorf("expected state to eq %q, got %q", StateClosed, state)
+		}
+	})
+
+	t.Run("probes after the interval", func(t *testing.T) {
+		c := &clock{t: time.Now()}
+		b := newTestBreaker(c)
+		th := &Throttle{Breaker: b}
+
+		var transitions []State
+		b.OnStateChange = func(from, to State) {
+			transitions = append(transitions, to)
+		}
+
+		for i := 0; i < 3; i++ {
+			th.Wait(context.Background())
+			finish(th, 500)
+		}
+		_, opened := b.State()
+
+		c.advance(time.Minute)
+		if err := th.Wait(context.Background()); err != nil {
+			t.Fatalf("expected the probe to be allowed, got %s", err)
+		}
+		if err := th.Wait(context.Background()); err != ErrOpen {
+			t.Errorf("expected a second probe to be rejected, got %v", err)
+		}
+
+		finish(th, 500)
+		if state, since := b.State(); state != StateOpen || !since.Equal(opened) {
+			t.Errorf("expected the breaker to stay open since %s, got %q since %s", opened, state, since)
+		}
+
+		c.advance(time.Minute)
+		if err := th.Wait(context.Background()); err != nil {
+			t.Fatalf("expected the probe to be allowed, got %s", err)
+		}
+		finish(th, 200)
+
+		expected := []State{StateOpen, StateHalfOpen, StateOpen, StateHalfOpen, StateClosed}
+		if len(transitions) != len(expected) {
+			t.Fatalf("expected transitions to eq %v, got %v", expected, transitions)
+		}
+		for i := range expected {
+			if transitions[i] != expected[i] {
+				t.Errorf("expected transitions to eq %v, got %v", expected, transitions)
+				break
+			}
+		}
+	})
+
+	t.Run("never opens without a threshold", func(t *testing.T) {
+		th := &Throttle{Breaker: NewBreaker(0, time.Minute)}
+
+		for i := 0; i < 10; i++ {
+			if err := th.Wait(context.Background()); err != nil {
+				t.Fatalf("expected call %d to be allowed, got %s", i, err)
+			}
+			finish(th, 500)
+		}
+	})
+}
+
+func TestLimiter(t *testing.T) {
+	t.Run("allows bursts", func(t *testing.T) {
+		c := &clock{t: time.Now()}
+		l := NewLimiter(1, 3)
+		l.now = c.now
+
+		for i := 0; i < 3; i++ {
+			if d := l.reserve(); d != 0 {
+				t.Errorf("expected call %d not to wait, got %s", i, d)
+			}
+		}
+
+		if d := l.reserve(); d != time.Second {
+			t.Errorf("expected delay to eq %s, got %s", time.Second, d)
+		}
+
+		if d := l.reserve(); d != 2*time.Second {
+			t.Errorf("expected delay to eq %s, got %s", 2*time.Second, d)
+		}
+
+		c.advance(5 * time.Second)
+		if d := l.reserve(); d != 0 {
+			t.Errorf("expected refilled call not to wait, got %s", d)
+		}
+	})
+
+	t.Run("honours Retry-After", func(t *testing.T) {
+		c := &clock{t: time.Now()}
+		l := NewLimiter(0, 1)
+		l.now = c.now
+		th := &Throttle{Limiter: l}
+		req, _ := http.NewRequest("GET", "http://api", nil)
+
+		var requests int
+		th.Transport(respond(429, "10", &requests)).RoundTrip(req)
+		if d := l.reserve(); d != 10*time.Second {
+			t.Errorf("expected delay to eq %s, got %s", 10*time.Second, d)
+		}
+
+		c.advance(10 * time.Second)
+		th.Transport(respond(429, "", &requests)).RoundTrip(req)
+		if d := l.reserve(); d != DefaultRetryAfter {
+			t.Errorf("expected delay to eq %s, got %s", DefaultRetryAfter, d)
+		}
+	})
+
+	t.Run("stops waiting when the context is done", func(t *testing.T) {
+		l := NewLimiter(0, 1)
+		l.Pause(time.Hour)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if err := l.Wait(ctx); err != context.Canceled {
+			t.Errorf("expected err to eq %q, got %v", context.Canceled, err)
+		}
+	})
+}
+
+func TestTransient(t *testing.T) {
+	tcs := []struct {
+		scenario  string
+		err       error
+		transient bool
+	}{
+		{scenario: "without an error", err: nil},
+		{scenario: "with an open circuit breaker", err: ErrOpen, transient: true},
+		{scenario: "with a transport error", err: &url.Error{Op: "Get", URL: "http://api", Err: errors.New("connection refused")}, transient: true},
+		{scenario: "with a server error", err: statusError{code: 503}, transient: true},
+		{scenario: "with a rate limited response", err: statusError{code: 429}, transient: true},
+		{scenario: "with an unauthorized response", err: statusError{code: 401}},
+		{scenario: "with a not found response", err: statusError{code: 404}},
+		{scenario: "with a plain error", err: errors.New("resource not found")},
+		{scenario: "with a wrapped server error", err: &url.Error{Op: "Get", URL: "http://api", Err: &StatusError{Code: 502}}, transient: true},
+		{scenario: "with a wrapped not found response", err: &url.Error{Op: "Get", URL: "http://api", Err: &StatusError{Code: 404}}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			if transient := Transient(tc.err); transient != tc.transient {
+				t.Errorf("expected transient to eq %t, got %t", tc.transient, transient)
+			}
+		})
+	}
+}
+
+func TestTransport(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://api", nil)
+
+	t.Run("takes a token for every request", func(t *testing.T) {
+		c := &clock{t: time.Now()}
+		l := NewLimiter(1, 2)
+		l.now = c.now
+		th := &Throttle{Limiter: l}
+
+		var requests int
+		rt := th.Transport(respond(200, "", &requests))
+		for i := 0; i < 2; i++ {
+			if _, err := rt.RoundTrip(req); err != nil {
+				t.Fatalf("expected request %d to succeed, got %s", i, err)
+			}
+		}
+
+		if d := l.reserve(); d != time.Second {
+			t.Errorf("expected the requests to use up the burst, got a delay of %s", d)
+		}
+	})
+
+	t.Run("only counts transport errors and server errors as failures", func(t *testing.T) {
+		tcs := []struct {
+			scenario string
+			code     int
+			state    State
+		}{
+			{scenario: "with transport errors", code: 0, state: StateOpen},
+			{scenario: "with server errors", code: 502, state: StateOpen},
+			{scenario: "with not found responses", code: 404, state: StateClosed},
+			{scenario: "with unauthorized responses", code: 401, state: StateClosed},
+			{scenario: "with rate limited responses", code: 429, state: StateClosed},
+		}
+
+		for _, tc := range tcs {
+			t.Run(tc.scenario, func(t *testing.T) {
+				th := &Throttle{Breaker: newTestBreaker(&clock{t: time.Now()}), RetryAfter: time.Nanosecond}
+
+				var requests int
+				rt := th.Transport(respond(tc.code, "", &requests))
+				for i := 0; i < 3; i++ {
+					rt.RoundTrip(req)
+				}
+
+				if state, _ := th.Breaker.State(); state != tc.state {
+					t.Errorf("expected state to eq %q, got %q", tc.state, state)
+				}
+			})
+		}
+	})
+
+	t.Run("doesn't send requests while the breaker is open", func(t *testing.T) {
+		th := &Throttle{Breaker: newTestBreaker(&clock{t: time.Now()})}
+
+		var requests int
+		rt := th.Transport(respond(500, "", &requests))
+		for i := 0; i < 4; i++ {
+			rt.RoundTrip(req)
+		}
+
+		if _, err := rt.RoundTrip(req); err != ErrOpen {
+			t.Errorf("expected err to eq %q, got %v", ErrOpen, err)
+		}
+		if requests != 3 {
+			t.Errorf("expected 3 requests to be sent, got %d", requests)
+		}
+	})
+}
+
+func TestTransport_statusError(t *testing.T) {
+	tcs := []struct {
+		scenario    string
+		code        int
+		contentType string
+		body        string
+		statusError bool
+	}{
+		{scenario: "with a proxy error page", code: 502, contentType: "text/html", body: "<html>Bad Gateway</html>", statusError: true},
+		{scenario: "without a content type", code: 503, body: "no healthy upstream", statusError: true},
+		{scenario: "with a not found error page", code: 404, contentType: "text/html", body: "<html>Not Found</html>", statusError: true},
+		{scenario: "with a JSON error", code: 503, contentType: "application/json; charset=utf-8", body: `{"type":"internal"}`},
+		{scenario: "with a JSON problem", code: 500, contentType: "application/problem+json", body: `{"title":"internal"}`},
+		{scenario: "with a successful response", code: 200, contentType: "text/plain", body: "ok"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.scenario, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header()["Content-Type"] = nil
+				if tc.contentType != "" {
+					w.Header().Set("Content-Type", tc.contentType)
+				}
+				w.WriteHeader(tc.code)
+				fmt.Fprint(w, tc.body)
+			}))
+			defer srv.Close()
+
+			th := &Throttle{}
+			client := &http.Client{Transport: th.Transport(http.DefaultTransport)}
+			resp, err := client.Get(srv.URL)
+
+			if !tc.statusError {
+				if err != nil {
+					t.Fatalf("expected no error, got %q", err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != tc.code {
+					t.Errorf("expected status %d, got %d", tc.code, resp.StatusCode)
+				}
+				return
+			}
+
+			ue, ok := err.(*url.Error)
+			if !ok {
+				t.Fatalf("expected a *url.Error, got %#v", err)
+			}
+			se, ok := ue.Err.(*StatusError)
+			if !ok {
+				t.Fatalf("expected a *StatusError, got %#v", ue.Err)
+			}
+			if se.StatusCode() != tc.code {
+				t.Errorf("expected status %d, got %d", tc.code, se.StatusCode())
+			}
+			if transient := Transient(err); transient != (tc.code >= 500) {
+				t.Errorf("expected transient to eq %t, got %t", tc.code >= 500, transient)
+			}
+		})
+	}
+}
+
+func TestIsOpen(t *testing.T) {
+	if !IsOpen(ErrOpen) {
+		t.Error("expected ErrOpen to be open")
+	}
+	if !IsOpen(&url.Error{Op: "Get", URL: "http://api", Err: ErrOpen}) {
+		t.Error("expected a wrapped ErrOpen to be open")
+	}
+	if IsOpen(&url.Error{Op: "Get", URL: "http://api", Err: errors.New("connection refused")}) {
+		t.Error("expected a transport error not to be open")
+	}
+}
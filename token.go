@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/manifoldco/go-manifold"
+	"github.com/manifoldco/go-manifold/integrations"
+
+	"github.com/manifoldco/kubernetes-credentials/controller"
+)
+
+// tokenReloader loads the Manifold API token, and optionally the team, from
+// files and rebuilds the Manifold client whenever their contents change. The
+// files are polled rather than watched: Secret volumes are updated through an
+// atomic swap of a symlinked directory, which file watches on the token files
+// themselves don't see. A new token is validated against the API before it is
+// used; if it's invalid, the previous client is kept.
+type tokenReloader struct {
+	tokenFile string
+	teamFile  string
+	interval  time.Duration
+	update    func(controller.CredentialSource, string)
+
+	token []byte
+	team  []byte
+}
+
+// load reads the token files and returns a validated client for them. It
+// returns a nil client when the contents haven't changed since the last
+// successful load.
+func (r *tokenReloader) load(ctx context.Context) (*integrations.Client, error) {
+	token, err := readTokenFile(r.tokenFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(token) == 0 {
+		return nil, fmt.Errorf("token file '%s' is empty", r.tokenFile)
+	}
+
+	var team []byte
+	if r.teamFile != "" {
+		team, err = readTokenFile(r.teamFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if bytes.Equal(token, r.token) && bytes.Equal(team, r.team) {
+		return nil, nil
+	}
+
+	cl, err := newValidatedManifoldClient(ctx, string(token), string(team))
+	if err != nil {
+		return nil, err
+	}
+
+	r.token = token
+	r.team = team
+	return cl, nil
+}
+
+// Run checks the token files for changes every interval until the context is
+// cancelled.
+func (r *tokenReloader) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		cl, err := r.load(ctx)
+		if err != nil {
+			log.WithError(err).Error("could not reload the Manifold API token, keeping the previous one")
+			continue
+		}
+
+		if cl != nil {
+			r.update(cl, string(r.team))
+			log.Info("Reloaded the Manifold API token")
+		}
+	}
+}
+
+// newValidatedManifoldClient builds a Manifold client for the given token and
+// team and verifies the token by looking up the user it belongs to.
+func newValidatedManifoldClient(ctx context.Context, token, team string) (*integrations.Client, error) {
+	mc := newManifoldAPIClient(token)
+	if _, err := mc.Self.Get(ctx); err != nil {
+		return nil, fmt.Errorf("could not validate the Manifold API token: %s", err)
+	}
+
+	return integrations.NewClient(mc, &team)
+}
+
+func readTokenFile(path string) ([]byte, error) {
+	bts, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read '%s': %s", path, err)
+	}
+
+	return []byte(strings.TrimSpace(string(bts))), nil
+}
+
+// newManifoldAPIClient builds a Manifold API client for the given token. The
+// API can be replaced, for example with the stand-in server of the
+// manifoldtest package, by setting MANIFOLD_API_URL_PATTERN.
+func newManifoldAPIClient(token string) *manifold.Client {
+	opts := []manifold.ConfigFunc{
+		manifold.WithAPIToken(token),
+		manifold.WithUserAgent(fmt.Sprintf("kubernetes-%s", Version)),
+	}
+	if pattern := os.Getenv("MANIFOLD_API_URL_PATTERN"); pattern != "" {
+		opts = append(opts, manifold.ForURLPattern(pattern))
+	}
+
+	return manifold.New(opts...)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/manifoldco/kubernetes-credentials/manifest"
+)
+
+// runValidate checks the Projects and Resources in the given manifests for
+// problems the controller would run into, without access to the cluster or
+// the Manifold API. It exits with 1 when there are any.
+func runValidate(args []string) int {
+	fs := newFlagSet("validate", "<file or directory>...", "Checks the Projects and Resources in the given manifests for problems, without access to a cluster or the Manifold API.")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	objects, err := manifest.Load(fs.Args()...)
+
+	var errs manifest.Errors
+	switch err := err.(type) {
+	case nil:
+	case manifest.Errors:
+		errs = append(errs, err...)
+	default:
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	// Projects and Resources write a secret with their own name, so two of
+	// them with the same name in a namespace overwrite each other's secret.
+	secrets := map[string]*manifest.Object{}
+	for _, obj := range objects {
+		errs = append(errs, obj.Validate()...)
+
+		meta := obj.Meta()
+		if meta.Name == "" {
+			continue
+		}
+
+		key := meta.Namespace + "/" + meta.Name
+		if other, ok := secrets[key]; ok {
+			errs = append(errs, &manifest.Error{
+				File:    obj.File,
+				Line:    obj.LineOf("name:"),
+				Message: fmt.Sprintf("metadata.name: secret '%s' is also written by the %s at %s:%d", meta.Name, other.Kind(), other.File, other.Line),
+			})
+			continue
+		}
+		secrets[key] = obj
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].File != errs[j].File {
+			return errs[i].File < errs[j].File
+		}
+
+		return errs[i].Line < errs[j].Line
+	})
+
+	for _, e := range errs {
+		fmt.Println(e)
+	}
+
+	if len(errs) > 0 {
+		fmt.Fprintf(os.Stderr, "found %d problems in %d Projects and Resources\n", len(errs), len(objects))
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "%d Projects and Resources are valid\n", len(objects))
+	return 0
+}
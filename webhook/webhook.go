@@ -0,0 +1,276 @@
+// Package webhook implements a mutating admission webhook which injects the
+// secret of a Project into the pods that reference it by annotation, so the
+// credentials don't have to be listed key by key in every pod spec.
+//
+// The webhook fails closed: a pod referencing a Project that doesn't exist or
+// whose secret isn't ready is rejected.
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+// VolumeName is the name of the volume the secret is mounted from.
+const VolumeName = "manifold-credentials"
+
+// ProjectGetter returns the Project with the given name in the given
+// namespace.
+type ProjectGetter func(namespace, name string) (*primitives.Project, error)
+
+// Handler serves the AdmissionReview requests of the API server.
+type Handler struct {
+	getProject ProjectGetter
+}
+
+// New returns a Handler which looks up Projects with the given getter.
+func New(getProject ProjectGetter) *Handler {
+	return &Handler{getProject: getProject}
+}
+
+// ServeHTTP handles a single AdmissionReview.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var review admissionv1beta1.AdmissionReview
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil || review.Request == nil {
+		http.Error(w, "expected an AdmissionReview request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = h.admit(review.Request)
+	review.Response.UID = review.Request.UID
+	review.Request = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&review); err != nil {
+		log.WithError(err).Error("could not write the admission response")
+	}
+}
+
+// admit returns the response for an admission request. Only pods are mutated,
+// when they're created. Pods without the project annotation are allowed as is.
+func (h *Handler) admit(req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	if req.Resource.Resource != "pods" || req.Operation != admissionv1beta1.Create {
+		return &admissionv1beta1.AdmissionResponse{Allowed: true}
+	}
+
+	var pod v1.Pod
+	if err := json.Unmarshal(req.Object.Raw, &pod); err != nil {
+		return deny(http.StatusBadRequest, "could not decode the pod: %s", err)
+	}
+
+	name := pod.Annotations[primitives.AnnotationProject]
+	if name == "" {
+		return &admissionv1beta1.AdmissionResponse{Allowed: true}
+	}
+
+	l := log.WithFields(log.Fields{
+		"pod_namespace": req.Namespace,
+		"pod_name":      pod.Name + pod.GenerateName,
+		"crd_name":      name,
+	})
+
+	project, err := h.getProject(req.Namespace, name)
+	switch {
+	case apierrors.IsNotFound(err):
+		l.Info("rejecting pod referencing an unknown project")
+		return deny(http.StatusForbidden, "Project '%s' does not exist", name)
+	case err != nil:
+		l.WithError(err).Error("could not get project")
+		return deny(http.StatusInternalServerError, "could not get Project '%s': %s", name, err)
+	}
+
+	ready := project.Status.Condition(primitives.ConditionReady)
+	if ready == nil || ready.Status != v1.ConditionTrue {
+		l.Info("rejecting pod referencing a project which isn't ready")
+		return deny(http.StatusForbidden, "Project '%s' is not Ready", name)
+	}
+
+	ops, err := patchPod(&pod, project.Name)
+	if err != nil {
+		return deny(http.StatusBadRequest, "%s", err)
+	}
+
+	resp := &admissionv1beta1.AdmissionResponse{Allowed: true}
+	if len(ops) == 0 {
+		return resp
+	}
+
+	patch, err := json.Marshal(ops)
+	if err != nil {
+		return deny(http.StatusInternalServerError, "could not encode the patch: %s", err)
+	}
+
+	pt := admissionv1beta1.PatchTypeJSONPatch
+	resp.Patch = patch
+	resp.PatchType = &pt
+	l.Debug("injecting project credentials into pod")
+	return resp
+}
+
+func deny(code int32, format string, args ...interface{}) *admissionv1beta1.AdmissionResponse {
+	return &admissionv1beta1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    code,
+			Message: fmt.Sprintf(format, args...),
+		},
+	}
+}
+
+// patchOp is a single JSON patch operation.
+type patchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// addOps returns the operations to append the values to the array at the given
+// path, which doesn't exist yet when it's empty.
+func addOps(path string, empty bool, values ...interface{}) []patchOp {
+	if len(values) == 0 {
+		return nil
+	}
+
+	if empty {
+		return []patchOp{{Op: "add", Path: path, Value: values}}
+	}
+
+	ops := make([]patchOp, len(values))
+	for i, v := range values {
+		ops[i] = patchOp{Op: "add", Path: path + "/-", Value: v}
+	}
+
+	return ops
+}
+
+// patchPod returns the operations which inject the secret into the pod. Keys
+// and mounts the pod already has are left alone, so the patch is empty when
+// the pod has been injected before.
+func patchPod(pod *v1.Pod, secret string) ([]patchOp, error) {
+	mountPath := pod.Annotations[primitives.AnnotationMountPath]
+	if mountPath != "" && !path.IsAbs(mountPath) {
+		return nil, fmt.Errorf("annotation %s needs to be an absolute path, got '%s'", primitives.AnnotationMountPath, mountPath)
+	}
+
+	containers := map[string]bool{}
+	for _, c := range append(append([]v1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+		containers[c.Name] = true
+	}
+	for k := range pod.Annotations {
+		if name := strings.TrimPrefix(k, primitives.AnnotationKeysPrefix); name != k && !containers[name] {
+			return nil, fmt.Errorf("annotation %s references unknown container '%s'", k, name)
+		}
+	}
+
+	var ops []patchOp
+	for i := range pod.Spec.InitContainers {
+		ops = append(ops, patchContainer(fmt.Sprintf("/spec/initContainers/%d", i), &pod.Spec.InitContainers[i], pod.Annotations, secret, mountPath)...)
+	}
+	for i := range pod.Spec.Containers {
+		ops = append(ops, patchContainer(fmt.Sprintf("/spec/containers/%d", i), &pod.Spec.Containers[i], pod.Annotations, secret, mountPath)...)
+	}
+
+	if mountPath != "" && !hasVolume(pod.Spec.Volumes, VolumeName) {
+		ops = append(ops, addOps("/spec/volumes", len(pod.Spec.Volumes) == 0, v1.Volume{
+			Name: VolumeName,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{SecretName: secret},
+			},
+		})...)
+	}
+
+	return ops, nil
+}
+
+func patchContainer(base string, c *v1.Container, annotations map[string]string, secret, mountPath string) []patchOp {
+	var ops []patchOp
+
+	if keys, ok := annotations[primitives.AnnotationKeysPrefix+c.Name]; ok {
+		var env []interface{}
+		for _, key := range strings.Split(keys, ",") {
+			key = strings.TrimSpace(key)
+			if key == "" || hasEnv(c.Env, key) {
+				continue
+			}
+
+			env = append(env, v1.EnvVar{
+				Name: key,
+				ValueFrom: &v1.EnvVarSource{
+					SecretKeyRef: &v1.SecretKeySelector{
+						LocalObjectReference: v1.LocalObjectReference{Name: secret},
+						Key:                  key,
+					},
+				},
+			})
+		}
+		ops = append(ops, addOps(base+"/env", len(c.Env) == 0, env...)...)
+	} else if !hasEnvFrom(c.EnvFrom, secret) {
+		ops = append(ops, addOps(base+"/envFrom", len(c.EnvFrom) == 0, v1.EnvFromSource{
+			SecretRef: &v1.SecretEnvSource{
+				LocalObjectReference: v1.LocalObjectReference{Name: secret},
+			},
+		})...)
+	}
+
+	if mountPath != "" && !hasVolumeMount(c.VolumeMounts, VolumeName) {
+		ops = append(ops, addOps(base+"/volumeMounts", len(c.VolumeMounts) == 0, v1.VolumeMount{
+			Name:      VolumeName,
+			MountPath: mountPath,
+			ReadOnly:  true,
+		})...)
+	}
+
+	return ops
+}
+
+func hasEnv(env []v1.EnvVar, name string) bool {
+	for _, e := range env {
+		if e.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasEnvFrom(env []v1.EnvFromSource, secret string) bool {
+	for _, e := range env {
+		if e.SecretRef != nil && e.SecretRef.Name == secret {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasVolume(volumes []v1.Volume, name string) bool {
+	for _, v := range volumes {
+		if v.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasVolumeMount(mounts []v1.VolumeMount, name string) bool {
+	for _, m := range mounts {
+		if m.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,182 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/manifoldco/kubernetes-credentials/primitives"
+)
+
+func readyProject(name string) *primitives.Project {
+	p := &primitives.Project{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+	p.Status.SetCondition(primitives.Condition{Type: primitives.ConditionReady, Status: v1.ConditionTrue})
+	return p
+}
+
+func getProjects(projects ...*primitives.Project) ProjectGetter {
+	return func(namespace, name string) (*primitives.Project, error) {
+		for _, p := range projects {
+			if p.Namespace == namespace && p.Name == name {
+				return p, nil
+			}
+		}
+
+		return nil, apierrors.NewNotFound(schema.GroupResource{Group: primitives.CRDGroup, Resource: primitives.CRDProjectsPlural}, name)
+	}
+}
+
+func podRequest(t *testing.T, pod *v1.Pod) *admissionv1beta1.AdmissionRequest {
+	raw, err := json.Marshal(pod)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	return &admissionv1beta1.AdmissionRequest{
+		Namespace: "default",
+		Operation: admissionv1beta1.Create,
+		Resource:  metav1.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Object:    runtime.RawExtension{Raw: raw},
+	}
+}
+
+func newPod(annotations map[string]string, containers ...v1.Container) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Annotations: annotations},
+		Spec:       v1.PodSpec{Containers: containers},
+	}
+}
+
+func patchOps(t *testing.T, resp *admissionv1beta1.AdmissionResponse) []patchOp {
+	if !resp.Allowed {
+		t.Fatalf("Expected the pod to be allowed, got '%s'", resp.Result.Message)
+	}
+
+	var ops []patchOp
+	if len(resp.Patch) == 0 {
+		return ops
+	}
+
+	if err := json.Unmarshal(resp.Patch, &ops); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	return ops
+}
+
+func TestAdmit(t *testing.T) {
+	h := New(getProjects(readyProject("my-project")))
+
+	t.Run("without the annotation", func(t *testing.T) {
+		resp := h.admit(podRequest(t, newPod(nil, v1.Container{Name: "app"})))
+		if ops := patchOps(t, resp); len(ops) != 0 {
+			t.Errorf("Expected no patch, got %v", ops)
+		}
+	})
+
+	t.Run("with envFrom", func(t *testing.T) {
+		pod := newPod(map[string]string{primitives.AnnotationProject: "my-project"}, v1.Container{Name: "app"})
+
+		ops := patchOps(t, h.admit(podRequest(t, pod)))
+		if len(ops) != 1 || ops[0].Path != "/spec/containers/0/envFrom" {
+			t.Fatalf("Expected a single envFrom operation, got %v", ops)
+		}
+	})
+
+	t.Run("with a mount path", func(t *testing.T) {
+		pod := newPod(map[string]string{
+			primitives.AnnotationProject:   "my-project",
+			primitives.AnnotationMountPath: "/etc/manifold",
+		}, v1.Container{Name: "app", VolumeMounts: []v1.VolumeMount{{Name: "data", MountPath: "/data"}}})
+
+		paths := map[string]bool{}
+		for _, op := range patchOps(t, h.admit(podRequest(t, pod))) {
+			paths[op.Path] = true
+		}
+
+		for _, p := range []string{"/spec/containers/0/envFrom", "/spec/containers/0/volumeMounts/-", "/spec/volumes"} {
+			if !paths[p] {
+				t.Errorf("Expected an operation for '%s', got %v", p, paths)
+			}
+		}
+	})
+
+	t.Run("with a key selection", func(t *testing.T) {
+		pod := newPod(map[string]string{
+			primitives.AnnotationProject:                "my-project",
+			primitives.AnnotationKeysPrefix + "sidecar": "TOKEN_ID, USERNAME",
+		}, v1.Container{Name: "app"}, v1.Container{Name: "sidecar", Env: []v1.EnvVar{{Name: "USERNAME", Value: "override"}}})
+
+		ops := patchOps(t, h.admit(podRequest(t, pod)))
+		if len(ops) != 2 {
+			t.Fatalf("Expected 2 operations, got %v", ops)
+		}
+
+		if ops[1].Path != "/spec/containers/1/env/-" {
+			t.Errorf("Expected the keys to be appended to the env, got '%s'", ops[1].Path)
+		}
+
+		env, _ := json.Marshal(ops[1].Value)
+		var ev v1.EnvVar
+		json.Unmarshal(env, &ev)
+		if ev.Name != "TOKEN_ID" || ev.ValueFrom.SecretKeyRef.Name != "my-project" {
+			t.Errorf("Expected TOKEN_ID from my-project, got %+v", ev)
+		}
+	})
+
+	t.Run("when already injected", func(t *testing.T) {
+		pod := newPod(map[string]string{primitives.AnnotationProject: "my-project"}, v1.Container{
+			Name: "app",
+			EnvFrom: []v1.EnvFromSource{{
+				SecretRef: &v1.SecretEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: "my-project"}},
+			}},
+		})
+
+		if ops := patchOps(t, h.admit(podRequest(t, pod))); len(ops) != 0 {
+			t.Errorf("Expected no patch, got %v", ops)
+		}
+	})
+
+	t.Run("fails closed", func(t *testing.T) {
+		notReady := readyProject("not-ready")
+		notReady.Status.SetCondition(primitives.Condition{Type: primitives.ConditionReady, Status: v1.ConditionFalse})
+		h := New(getProjects(notReady, readyProject("my-project")))
+
+		tcs := []struct {
+			scenario    string
+			annotations map[string]string
+		}{
+			{"with an unknown project", map[string]string{primitives.AnnotationProject: "unknown"}},
+			{"with a project which isn't ready", map[string]string{primitives.AnnotationProject: "not-ready"}},
+			{"with a relative mount path", map[string]string{primitives.AnnotationProject: "my-project", primitives.AnnotationMountPath: "etc"}},
+			{"with an unknown container", map[string]string{primitives.AnnotationProject: "my-project", primitives.AnnotationKeysPrefix + "db": "TOKEN"}},
+		}
+
+		for _, tc := range tcs {
+			t.Run(tc.scenario, func(t *testing.T) {
+				resp := h.admit(podRequest(t, newPod(tc.annotations, v1.Container{Name: "app"})))
+				if resp.Allowed {
+					t.Error("Expected the pod to be denied")
+				}
+			})
+		}
+	})
+
+	t.Run("when the lookup fails", func(t *testing.T) {
+		h := New(func(namespace, name string) (*primitives.Project, error) {
+			return nil, errors.New("connection refused")
+		})
+
+		resp := h.admit(podRequest(t, newPod(map[string]string{primitives.AnnotationProject: "my-project"}, v1.Container{Name: "app"})))
+		if resp.Allowed {
+			t.Error("Expected the pod to be denied")
+		}
+	})
+}